@@ -0,0 +1,85 @@
+// Command x-grpc-server is a reference implementation of
+// provider.v1.LocalProvider (see proto/provider/v1/provider.proto). It
+// wraps an existing Ollama installation so a warm model stays resident
+// behind a single long-running process instead of `x` cold-starting an
+// HTTP request per invocation; point GRPC_PROVIDER_ADDR at it to have `x`
+// pick it up as the "grpc-local" provider. Swap the Ollama backend for
+// llama.cpp's server mode or any other model process by implementing the
+// same LocalProviderServer interface.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+
+	"google.golang.org/grpc"
+
+	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+	"github.com/REDFOX1899/ask-sh/internal/provider/grpcpb"
+)
+
+func main() {
+	addr := flag.String("addr", ":50051", "address to listen on")
+	ollamaHost := flag.String("ollama-host", config.DefaultOllamaHost, "Ollama host to wrap")
+	ollamaModel := flag.String("ollama-model", "llama3", "Ollama model to serve")
+	flag.Parse()
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("listen on %s: %v", *addr, err)
+	}
+
+	backend := provider.NewOllama(&config.Config{OllamaHost: *ollamaHost, OllamaModel: *ollamaModel}, false)
+
+	s := grpc.NewServer()
+	grpcpb.RegisterLocalProviderServer(s, &server{backend: backend})
+
+	log.Printf("x-grpc-server listening on %s, wrapping Ollama model %q at %s", *addr, *ollamaModel, *ollamaHost)
+	if err := s.Serve(lis); err != nil {
+		log.Fatalf("serve: %v", err)
+	}
+}
+
+// server adapts a provider.Provider to the LocalProvider gRPC service, so
+// any existing provider implementation can be exposed as a warm backend
+// without duplicating its request logic.
+type server struct {
+	grpcpb.UnimplementedLocalProviderServer
+	backend *provider.Ollama
+}
+
+func (s *server) Generate(req *grpcpb.GenerateRequest, stream grpcpb.LocalProvider_GenerateServer) error {
+	tokens, err := s.backend.StreamCommand(stream.Context(), req.GetPrompt())
+	if err != nil {
+		return err
+	}
+
+	for tok := range tokens {
+		if tok.Err != nil {
+			return tok.Err
+		}
+		if err := stream.Send(&grpcpb.Token{Text: tok.Text, Done: tok.Done}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) Explain(ctx context.Context, req *grpcpb.ExplainRequest) (*grpcpb.ExplainResponse, error) {
+	explanation, err := s.backend.ExplainCommand(ctx, req.GetCommand())
+	if err != nil {
+		return nil, err
+	}
+	return &grpcpb.ExplainResponse{Explanation: explanation}, nil
+}
+
+func (s *server) Refine(ctx context.Context, req *grpcpb.RefineRequest) (*grpcpb.RefineResponse, error) {
+	resp, err := s.backend.RefineCommand(ctx, req.GetCommand(), req.GetRefinement())
+	if err != nil {
+		return nil, err
+	}
+	return &grpcpb.RefineResponse{Command: resp.Command}, nil
+}
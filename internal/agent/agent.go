@@ -0,0 +1,251 @@
+// Package agent implements an opt-in tool-calling loop: the model is given
+// a small set of declared tools, and side-effecting tool calls require
+// user confirmation before they run.
+package agent
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+)
+
+// Options configures an agent run.
+type Options struct {
+	MaxSteps int      // give up after this many tool-calling turns
+	WorkDir  string   // restricted working directory for file/command tools
+	DryRun   bool     // when true, run_command only describes commands; when false, the model's dry_run argument decides
+	Allow    []string // command prefixes that are allowed; empty means allow everything not denied
+	Deny     []string // command prefixes that are always blocked, checked before Allow
+}
+
+// Agent drives a tool-calling loop against a provider.ToolProvider.
+type Agent struct {
+	provider provider.ToolProvider
+	opts     Options
+	reader   *bufio.Reader
+}
+
+// New creates an Agent, defaulting MaxSteps and WorkDir when unset.
+func New(p provider.ToolProvider, opts Options) *Agent {
+	if opts.MaxSteps <= 0 {
+		opts.MaxSteps = 10
+	}
+	if opts.WorkDir == "" {
+		opts.WorkDir, _ = os.Getwd()
+	}
+	return &Agent{provider: p, opts: opts, reader: bufio.NewReader(os.Stdin)}
+}
+
+// Tools returns the agent's fixed tool set, declared in each provider's
+// native tool-calling schema via provider.Tool.
+func Tools() []provider.Tool {
+	return []provider.Tool{
+		{
+			Name:        "run_command",
+			Description: "Run a shell command inside the agent's restricted working directory.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"cmd":     map[string]interface{}{"type": "string", "description": "The shell command to run"},
+					"dry_run": map[string]interface{}{"type": "boolean", "description": "If true, describe the command's effect instead of running it"},
+				},
+				"required": []string{"cmd"},
+			},
+		},
+		{
+			Name:        "read_file",
+			Description: "Read a file's contents, relative to the agent's working directory.",
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"path": map[string]interface{}{"type": "string"}},
+				"required":   []string{"path"},
+			},
+		},
+		{
+			Name:        "list_dir",
+			Description: "List a directory's entries, relative to the agent's working directory.",
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"path": map[string]interface{}{"type": "string"}},
+				"required":   []string{"path"},
+			},
+		},
+		{
+			Name:        "ask_user",
+			Description: "Ask the user a clarifying question and wait for their answer.",
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"question": map[string]interface{}{"type": "string"}},
+				"required":   []string{"question"},
+			},
+		},
+	}
+}
+
+// Run drives the tool-calling loop: send the instruction and tool
+// definitions, execute whatever tools the model asks for (confirming
+// side-effecting ones), feed results back, and repeat until the model
+// returns a final answer or MaxSteps is exceeded.
+func (a *Agent) Run(ctx context.Context, instruction string) (string, error) {
+	history := []provider.HistoryMessage{{Role: "user", Content: instruction}}
+	tools := Tools()
+
+	for step := 0; step < a.opts.MaxSteps; step++ {
+		resp, err := a.provider.GenerateWithTools(ctx, history, tools)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.ToolCalls) == 0 {
+			return resp.Text, nil
+		}
+
+		history = append(history, provider.HistoryMessage{Role: "assistant", ToolCalls: resp.ToolCalls})
+		for _, call := range resp.ToolCalls {
+			result := a.executeTool(call)
+			history = append(history, provider.HistoryMessage{
+				Role:       "tool",
+				Content:    result,
+				ToolName:   call.Name,
+				ToolCallID: call.ID,
+			})
+		}
+	}
+
+	return "", fmt.Errorf("agent exceeded max steps (%d) without a final answer", a.opts.MaxSteps)
+}
+
+// executeTool runs a single tool call and returns its result as text to
+// feed back to the model.
+func (a *Agent) executeTool(call provider.ToolCall) string {
+	switch call.Name {
+	case "run_command":
+		return a.runCommand(call.Arguments)
+	case "read_file":
+		return a.readFile(call.Arguments)
+	case "list_dir":
+		return a.listDir(call.Arguments)
+	case "ask_user":
+		return a.askUser(call.Arguments)
+	default:
+		return fmt.Sprintf("error: unknown tool %q", call.Name)
+	}
+}
+
+func (a *Agent) runCommand(args map[string]interface{}) string {
+	cmdStr, _ := args["cmd"].(string)
+	if cmdStr == "" {
+		return "error: missing cmd"
+	}
+
+	if !a.commandAllowed(cmdStr) {
+		return fmt.Sprintf("error: command %q is blocked by the agent's allow/deny list", cmdStr)
+	}
+
+	dryRun := a.opts.DryRun
+	if v, ok := args["dry_run"].(bool); ok {
+		dryRun = dryRun || v
+	}
+	if dryRun {
+		return fmt.Sprintf("dry run: would execute %q in %s", cmdStr, a.opts.WorkDir)
+	}
+
+	if !a.confirm(fmt.Sprintf("Run command: %s", cmdStr)) {
+		return "user declined to run this command"
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", cmdStr)
+	cmd.Dir = a.opts.WorkDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Sprintf("command failed: %v\noutput:\n%s", err, out)
+	}
+	return string(out)
+}
+
+func (a *Agent) readFile(args map[string]interface{}) string {
+	path, _ := args["path"].(string)
+	if path == "" {
+		return "error: missing path"
+	}
+	full, err := a.resolvePath(path)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err)
+	}
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return fmt.Sprintf("error reading file: %v", err)
+	}
+	return string(data)
+}
+
+func (a *Agent) listDir(args map[string]interface{}) string {
+	path, _ := args["path"].(string)
+	if path == "" {
+		path = "."
+	}
+	full, err := a.resolvePath(path)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err)
+	}
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return fmt.Sprintf("error listing directory: %v", err)
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return strings.Join(names, "\n")
+}
+
+func (a *Agent) askUser(args map[string]interface{}) string {
+	question, _ := args["question"].(string)
+	fmt.Printf("%s ", question)
+	answer, _ := a.reader.ReadString('\n')
+	return strings.TrimSpace(answer)
+}
+
+// resolvePath resolves path relative to the agent's working directory and
+// rejects any path that would escape it.
+func (a *Agent) resolvePath(path string) (string, error) {
+	full := filepath.Join(a.opts.WorkDir, path)
+	rel, err := filepath.Rel(a.opts.WorkDir, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q escapes the agent's working directory", path)
+	}
+	return full, nil
+}
+
+// commandAllowed checks cmd's prefix against the deny list first, then the
+// allow list, so an explicit deny always wins. An empty allow list means
+// everything not denied is allowed.
+func (a *Agent) commandAllowed(cmd string) bool {
+	for _, prefix := range a.opts.Deny {
+		if strings.HasPrefix(cmd, prefix) {
+			return false
+		}
+	}
+	if len(a.opts.Allow) == 0 {
+		return true
+	}
+	for _, prefix := range a.opts.Allow {
+		if strings.HasPrefix(cmd, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// confirm asks the user to confirm a side-effecting action.
+func (a *Agent) confirm(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+	answer, _ := a.reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,118 @@
+// Package audit persists a structured trail of every command generation
+// and execution - including hook decisions along the way - to
+// ~/.x/audit.jsonl, so operators have a record comparable to what tools
+// like syft write for a dependency scan, without instrumenting the cli
+// package directly.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/REDFOX1899/ask-sh/internal/hooks"
+)
+
+// Entry is one record in the audit trail: either a generation or an
+// execution, with however long it took and what the hook pipeline decided
+// along the way.
+type Entry struct {
+	Timestamp   time.Time        `json:"timestamp"`
+	Phase       hooks.Phase      `json:"phase"`
+	Instruction string           `json:"instruction,omitempty"`
+	Command     string           `json:"command,omitempty"`
+	Provider    string           `json:"provider,omitempty"`
+	Model       string           `json:"model,omitempty"`
+	Duration    time.Duration    `json:"duration,omitempty"`
+	ExitCode    int              `json:"exit_code,omitempty"`
+	Decisions   []hooks.Decision `json:"decisions,omitempty"`
+}
+
+// Log appends to and reads back ~/.x/audit.jsonl, one JSON object per line
+// so it can also be tailed or grepped like any other log.
+type Log struct {
+	path string
+}
+
+// DefaultPath returns ~/.x/audit.jsonl.
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".x", "audit.jsonl"), nil
+}
+
+// Open opens the audit log at its default location, creating ~/.x if
+// needed. The file itself is created lazily, on the first Append.
+func Open() (*Log, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return &Log{path: path}, nil
+}
+
+// Append records one entry, stamping it with the current time.
+func (l *Log) Append(e Entry) error {
+	e.Timestamp = time.Now()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// All reads every recorded entry, oldest first. A log file that doesn't
+// exist yet is not an error - it just means there's no audit trail.
+func (l *Log) All() ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Tail returns the last n entries, oldest first, for `x audit tail`.
+func (l *Log) Tail(n int) ([]Entry, error) {
+	entries, err := l.All()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/REDFOX1899/ask-sh/internal/agent"
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+	"github.com/REDFOX1899/ask-sh/internal/ui"
+)
+
+var (
+	agentMaxSteps int
+	agentAllow    []string
+	agentDeny     []string
+	agentExecute  bool
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent [instruction]",
+	Short: "Run an interactive, tool-calling agent",
+	Long: `agent gives the model a small set of tools (run_command, read_file,
+list_dir, ask_user) and loops: send prompt and tool definitions, run
+whatever tool the model asks for, feed the result back, repeat until the
+model returns a final answer.
+
+Side-effecting commands are dry-run by default and require a y/n
+confirmation before they actually execute; pass --execute to run commands
+for real.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAgent,
+}
+
+func init() {
+	agentCmd.Flags().IntVar(&agentMaxSteps, "max-steps", 10, "Maximum number of tool-calling turns before giving up")
+	agentCmd.Flags().StringSliceVar(&agentAllow, "allow", nil, "Command prefixes allowed to run (default: allow all not denied)")
+	agentCmd.Flags().StringSliceVar(&agentDeny, "deny", nil, "Command prefixes that are always blocked")
+	agentCmd.Flags().BoolVar(&agentExecute, "execute", false, "Actually run commands instead of only dry-running them")
+	rootCmd.AddCommand(agentCmd)
+}
+
+func runAgent(cmd *cobra.Command, args []string) error {
+	instruction := strings.Join(args, " ")
+
+	p, err := detectProvider()
+	if err != nil {
+		return err
+	}
+
+	tp, ok := p.(provider.ToolProvider)
+	if !ok {
+		err := fmt.Errorf("provider %s does not support tool calling", p.Name())
+		ui.PrintError(err.Error())
+		return err
+	}
+
+	a := agent.New(tp, agent.Options{
+		MaxSteps: agentMaxSteps,
+		DryRun:   !agentExecute,
+		Allow:    agentAllow,
+		Deny:     agentDeny,
+	})
+
+	answer, err := a.Run(context.Background(), instruction)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Agent failed: %v", err))
+		return err
+	}
+
+	fmt.Println(answer)
+	return nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/REDFOX1899/ask-sh/internal/audit"
+)
+
+// defaultAuditTailCount is how many entries `x audit tail` shows when no
+// count is given, matching the repo's other small human-facing defaults
+// (pastFailuresForFix, etc.) rather than dumping the whole log.
+const defaultAuditTailCount = 20
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the hook and execution audit trail at ~/.x/audit.jsonl",
+}
+
+var auditTailCmd = &cobra.Command{
+	Use:   "tail [N]",
+	Short: "Show the last N audit entries (default 20)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n := defaultAuditTailCount
+		if len(args) == 1 {
+			parsed, err := strconv.Atoi(args[0])
+			if err != nil || parsed <= 0 {
+				return fmt.Errorf("invalid count %q", args[0])
+			}
+			n = parsed
+		}
+
+		log, err := audit.Open()
+		if err != nil {
+			return fmt.Errorf("opening audit log: %w", err)
+		}
+
+		entries, err := log.Tail(n)
+		if err != nil {
+			return fmt.Errorf("reading audit log: %w", err)
+		}
+		if len(entries) == 0 {
+			fmt.Println("(no audit entries yet)")
+			return nil
+		}
+
+		for _, e := range entries {
+			fmt.Printf("%s  %-13s  %s", e.Timestamp.Format("2006-01-02 15:04:05"), e.Phase, e.Command)
+			if e.ExitCode != 0 {
+				fmt.Printf("  (exit %d)", e.ExitCode)
+			}
+			fmt.Println()
+			for _, d := range e.Decisions {
+				if d.Veto {
+					fmt.Printf("    vetoed: %s\n", d.Reason)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	auditCmd.AddCommand(auditTailCmd)
+	rootCmd.AddCommand(auditCmd)
+}
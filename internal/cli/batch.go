@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/exec"
+	"github.com/REDFOX1899/ask-sh/internal/hooks"
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+	"github.com/REDFOX1899/ask-sh/internal/safety"
+	"github.com/REDFOX1899/ask-sh/internal/session"
+	"github.com/REDFOX1899/ask-sh/internal/ui"
+)
+
+// batchResult is one line of `--stdin`/`--json` output: the generated
+// command plus, when it was actually run, its outcome. Fields that don't
+// apply to a given mode (Explanation when the model didn't produce one,
+// Stdout/Stderr when --yes wasn't given) are omitted rather than printed
+// empty.
+type batchResult struct {
+	Instruction string `json:"instruction"`
+	Provider    string `json:"provider,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Command     string `json:"command,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+	Executed    bool   `json:"executed"`
+	ExitCode    int    `json:"exit_code"`
+	Stdout      string `json:"stdout,omitempty"`
+	Stderr      string `json:"stderr,omitempty"`
+	DurationMS  int64  `json:"duration_ms"`
+	Error       string `json:"error,omitempty"`
+}
+
+// runSingleShot handles the non-TUI flows for a single instruction whose
+// command has already been generated and safety-gated by runCommand:
+// --refine, --explain-only, --json (print only), and --yes (execute
+// directly). It's the single-instruction counterpart to runStdinBatch.
+func runSingleShot(ctx context.Context, p provider.Provider, hist *session.History, instruction string, resp *provider.Response) error {
+	if refineQuery != "" {
+		refined, err := p.RefineCommand(ctx, resp.Command, refineQuery)
+		if err != nil {
+			ui.PrintError(fmt.Sprintf("Failed to refine command: %v", err))
+			return err
+		}
+		resp = refined
+	}
+
+	if explainOnly {
+		explanation, err := p.ExplainCommand(ctx, resp.Command)
+		if err != nil {
+			ui.PrintError(fmt.Sprintf("Failed to explain command: %v", err))
+			return err
+		}
+		if jsonOutput {
+			return printBatchResult(batchResult{
+				Instruction: instruction,
+				Provider:    resp.Provider,
+				Model:       resp.Model,
+				Command:     resp.Command,
+				Explanation: explanation,
+			})
+		}
+		fmt.Println(explanation)
+		return nil
+	}
+
+	if !yesFlag {
+		return printBatchResult(batchResult{
+			Instruction: instruction,
+			Provider:    resp.Provider,
+			Model:       resp.Model,
+			Command:     resp.Command,
+			Explanation: resp.Explanation,
+		})
+	}
+
+	result := executeForBatch(ctx, p, hist, instruction, resp)
+	if jsonOutput {
+		return printBatchResult(result)
+	}
+
+	fmt.Print(result.Stdout)
+	fmt.Fprint(os.Stderr, result.Stderr)
+	if result.Error != "" {
+		return fmt.Errorf("%s", result.Error)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("command exited with status %d", result.ExitCode)
+	}
+	return nil
+}
+
+// runStdinBatch reads one instruction per line (or a single JSON array of
+// strings) from stdin and, for each, emits one batchResult as a line of
+// JSON to stdout - the scriptable counterpart to the single-instruction
+// `x <instruction>` form. --explain-only and --refine don't apply here;
+// only --yes (execute each generated command) and the always-on JSON
+// encoding are relevant in this mode.
+func runStdinBatch(cfg *config.Config, registry *provider.Registry) error {
+	p, promptTemplate, err := resolveProvider(registry, cfg)
+	if err != nil {
+		return err
+	}
+
+	instructions, err := readBatchInstructions(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("reading stdin: %w", err)
+	}
+
+	hist, err := session.Open()
+	if err != nil {
+		return fmt.Errorf("opening session history: %w", err)
+	}
+
+	ctx := context.Background()
+	enc := json.NewEncoder(os.Stdout)
+	for _, instruction := range instructions {
+		if err := enc.Encode(processBatchInstruction(ctx, p, promptTemplate, hist, instruction, cfg)); err != nil {
+			return fmt.Errorf("encoding result: %w", err)
+		}
+	}
+	return nil
+}
+
+// processBatchInstruction runs one instruction through generateAndGate and,
+// with --yes, executes the resulting command - folding any failure along
+// the way into result.Error instead of aborting the rest of the batch.
+func processBatchInstruction(ctx context.Context, p provider.Provider, promptTemplate string, hist *session.History, instruction string, cfg *config.Config) batchResult {
+	resp, err := generateAndGate(ctx, p, promptTemplate, instruction, cfg)
+	if err != nil {
+		return batchResult{Instruction: instruction, Error: err.Error()}
+	}
+
+	result := batchResult{
+		Instruction: instruction,
+		Provider:    resp.Provider,
+		Model:       resp.Model,
+		Command:     resp.Command,
+		Explanation: resp.Explanation,
+	}
+
+	if !yesFlag {
+		return result
+	}
+
+	return executeForBatch(ctx, p, hist, instruction, resp)
+}
+
+// executeForBatch runs resp.Command non-interactively via exec.RunCaptured,
+// records the outcome to hist the same way executeWithFixLoop does for the
+// interactive loop, and returns it as a fully-populated batchResult.
+func executeForBatch(ctx context.Context, p provider.Provider, hist *session.History, instruction string, resp *provider.Response) batchResult {
+	result := batchResult{
+		Instruction: instruction,
+		Provider:    resp.Provider,
+		Model:       resp.Model,
+		Command:     resp.Command,
+		Explanation: resp.Explanation,
+		Executed:    true,
+	}
+
+	started := time.Now()
+	command, err := runHookPhase(ctx, hooks.PhasePreExecute, started, instruction, resp.Command, resp.Provider, resp.Model, 0)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Command = command
+
+	captured, err := exec.RunCaptured(command)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.ExitCode = captured.ExitCode
+	result.Stdout = captured.Stdout
+	result.Stderr = captured.Stderr
+	result.DurationMS = captured.Duration.Milliseconds()
+
+	if _, hookErr := runHookPhase(ctx, hooks.PhasePostExecute, started, instruction, command, resp.Provider, resp.Model, captured.ExitCode); hookErr != nil {
+		ui.PrintError(hookErr.Error())
+	}
+
+	outcome := "executed"
+	if captured.ExitCode != 0 {
+		outcome = "failed"
+	}
+	assessment := safety.AnalyzeCommand(command)
+	stderrTail := captured.Stderr
+	if lines := strings.Split(strings.TrimRight(stderrTail, "\n"), "\n"); len(lines) > exec.TailLines {
+		stderrTail = strings.Join(lines[len(lines)-exec.TailLines:], "\n")
+	}
+	_ = hist.Append(session.Entry{
+		Prompt:     instruction,
+		Command:    command,
+		Provider:   resp.Provider,
+		Model:      resp.Model,
+		RiskLevel:  safety.GetRiskLevelName(assessment.Level),
+		Outcome:    outcome,
+		ExitCode:   captured.ExitCode,
+		StderrTail: stderrTail,
+	})
+
+	return result
+}
+
+// readBatchInstructions accepts either a JSON array of strings or
+// newline-separated plain text, so --stdin works equally well fed from a
+// jq pipeline or a plain for-loop.
+func readBatchInstructions(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	if data[0] == '[' {
+		var instructions []string
+		if err := json.Unmarshal(data, &instructions); err != nil {
+			return nil, fmt.Errorf("parsing JSON array: %w", err)
+		}
+		return instructions, nil
+	}
+
+	var instructions []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			instructions = append(instructions, line)
+		}
+	}
+	return instructions, nil
+}
+
+// printBatchResult writes result to stdout as a single line of JSON.
+func printBatchResult(result batchResult) error {
+	return json.NewEncoder(os.Stdout).Encode(result)
+}
@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/REDFOX1899/ask-sh/internal/conversation"
+	"github.com/REDFOX1899/ask-sh/internal/ui"
+)
+
+var consoleResume string
+
+var consoleCmd = &cobra.Command{
+	Use:   "console",
+	Short: "Start a persistent console session against one provider connection",
+	Long: `console is a REPL like abci-cli's console mode: it keeps one provider
+connection warm across many natural-language requests instead of paying
+detection and prompt-build cost per invocation, and threads every turn
+through a persistent conversation so follow-ups like "now only for .go
+files" have full context.
+
+Every turn is saved to ~/.x/conversations/<id>.json as it happens; pass
+--resume <id> to continue a prior session where it left off.
+
+Slash-commands:
+  /provider <name>   Switch provider for the rest of the session
+  /model <alias>      Switch to a named model alias for the rest of the session
+  /history            Show this session's turns
+  /rerun <N>          Regenerate the Nth turn's instruction as a new turn
+  /save <alias>       Write the last generated command to ./<alias>
+  /clear              Start a fresh session, abandoning this one's context
+  /explain <cmd>      Explain a command without generating one
+  /exit               Leave the console, keeping the session on disk`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConsole()
+	},
+}
+
+func init() {
+	consoleCmd.Flags().StringVar(&consoleResume, "resume", "", "Resume a prior session by its conversation ID")
+	rootCmd.AddCommand(consoleCmd)
+}
+
+// consoleState carries the bits of a console session that slash-commands
+// mutate: which provider/model is active and the persisted conversation
+// backing every turn.
+type consoleState struct {
+	providerName string
+	modelAlias   string
+	conv         *conversation.Conversation
+	lastCommand  string
+}
+
+func runConsole() error {
+	store, err := conversation.NewStore()
+	if err != nil {
+		return err
+	}
+
+	var conv *conversation.Conversation
+	if consoleResume != "" {
+		conv, err = store.Load(consoleResume)
+		if err != nil {
+			return fmt.Errorf("resuming session %s: %w", consoleResume, err)
+		}
+	} else {
+		conv = store.New()
+	}
+
+	state := &consoleState{conv: conv}
+	ctx := context.Background()
+
+	fmt.Printf("x console - session %s, type /exit to leave (/history for this session's turns)\n", conv.ID)
+
+	reader := newLineReader(os.Stdin, nil)
+	defer reader.Close()
+
+	for {
+		line, err := reader.ReadLine("console> ")
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		reader.AddHistory(line)
+
+		if strings.HasPrefix(line, "/") {
+			handled, exit := handleConsoleCommand(ctx, state, store, line)
+			if exit {
+				return nil
+			}
+			if handled {
+				continue
+			}
+		}
+
+		if err := consoleTurn(ctx, state, store, line); err != nil {
+			ui.PrintError(err.Error())
+		}
+	}
+}
+
+// handleConsoleCommand processes one leading-slash meta-command. handled is
+// true if line was recognized, so the caller won't also treat it as a
+// natural-language instruction. exit is true if the console should leave.
+func handleConsoleCommand(ctx context.Context, state *consoleState, store *conversation.Store, line string) (handled bool, exit bool) {
+	fields := strings.Fields(line)
+	name := fields[0]
+	rest := strings.TrimSpace(strings.TrimPrefix(line, name))
+
+	switch name {
+	case "/exit", "/quit":
+		return true, true
+
+	case "/provider":
+		if rest == "" {
+			ui.PrintError("usage: /provider <name>")
+			return true, false
+		}
+		state.providerName = rest
+		state.modelAlias = ""
+		fmt.Printf("provider set to %s\n", rest)
+		return true, false
+
+	case "/model":
+		if rest == "" {
+			ui.PrintError("usage: /model <alias>")
+			return true, false
+		}
+		state.modelAlias = rest
+		fmt.Printf("model set to %s\n", rest)
+		return true, false
+
+	case "/history":
+		leaf, ok := state.conv.Leaf()
+		if !ok {
+			fmt.Println("(no turns yet)")
+			return true, false
+		}
+		for i, m := range state.conv.Branch(leaf.ID) {
+			fmt.Printf("[%d] %s: %s\n", i, m.Role, m.Content)
+		}
+		return true, false
+
+	case "/rerun":
+		if rest == "" {
+			ui.PrintError("usage: /rerun <N>")
+			return true, false
+		}
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			ui.PrintError("usage: /rerun <N>")
+			return true, false
+		}
+		leaf, ok := state.conv.Leaf()
+		if !ok {
+			ui.PrintError("no turns to rerun yet")
+			return true, false
+		}
+		branch := state.conv.Branch(leaf.ID)
+		if n < 0 || n >= len(branch) || branch[n].Role != "user" {
+			ui.PrintError(fmt.Sprintf("no user turn at index %d", n))
+			return true, false
+		}
+		if err := consoleTurn(ctx, state, store, branch[n].Content); err != nil {
+			ui.PrintError(err.Error())
+		}
+		return true, false
+
+	case "/save":
+		if rest == "" {
+			ui.PrintError("usage: /save <alias>")
+			return true, false
+		}
+		if state.lastCommand == "" {
+			ui.PrintError("no command to save yet")
+			return true, false
+		}
+		if err := os.WriteFile(rest, []byte(state.lastCommand+"\n"), 0644); err != nil {
+			ui.PrintError(err.Error())
+		}
+		return true, false
+
+	case "/clear":
+		state.conv = store.New()
+		state.lastCommand = ""
+		fmt.Printf("started fresh session %s\n", state.conv.ID)
+		return true, false
+
+	case "/explain":
+		if rest == "" {
+			ui.PrintError("usage: /explain <command>")
+			return true, false
+		}
+		p, err := resolveProviderFor(state.providerName, state.modelAlias)
+		if err != nil {
+			ui.PrintError(err.Error())
+			return true, false
+		}
+		explanation, err := explainCommand(ctx, p, rest)
+		if err != nil {
+			ui.PrintError(err.Error())
+			return true, false
+		}
+		fmt.Println(explanation)
+		return true, false
+	}
+
+	return false, false
+}
+
+// consoleTurn generates a command for a natural-language instruction,
+// feeding the session's conversation for context, then saves the turn to
+// disk immediately so --resume never loses work to a crash mid-session.
+func consoleTurn(ctx context.Context, state *consoleState, store *conversation.Store, instruction string) error {
+	p, err := resolveProviderFor(state.providerName, state.modelAlias)
+	if err != nil {
+		return err
+	}
+
+	leaf, hasLeaf := state.conv.Leaf()
+	parentID := ""
+	if hasLeaf {
+		parentID = leaf.ID
+	}
+	state.conv.AddMessage(conversation.Message{ParentID: parentID, Role: "user", Content: instruction})
+
+	resp, err := generateFromHistory(ctx, p, state.conv)
+	if err != nil {
+		return fmt.Errorf("generating command: %w", err)
+	}
+	newLeaf, _ := state.conv.Leaf()
+	state.conv.AddMessage(conversation.Message{ParentID: newLeaf.ID, Role: "assistant", Content: resp.Command, Provider: resp.Provider, Model: resp.Model})
+	state.lastCommand = resp.Command
+
+	if err := store.Save(state.conv); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+
+	fmt.Println(resp.Command)
+	return nil
+}
@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/REDFOX1899/ask-sh/internal/conversation"
+	"github.com/REDFOX1899/ask-sh/internal/prompt"
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+	"github.com/REDFOX1899/ask-sh/internal/ui"
+)
+
+var newCmd = &cobra.Command{
+	Use:   "new [instruction]",
+	Short: "Start a new persistent conversation",
+	Long:  `Start a new conversation stored under ~/.x/conversations, so later "x reply" calls can refine it with full context.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runConversationNew,
+}
+
+var replyCmd = &cobra.Command{
+	Use:   "reply <id> [text]",
+	Short: "Continue a conversation with a refinement",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runConversationReply,
+}
+
+var viewCmd = &cobra.Command{
+	Use:   "view <id>",
+	Short: "Show a conversation's current branch",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConversationView,
+}
+
+var editCmd = &cobra.Command{
+	Use:   "edit <id> <message-id> [text]",
+	Short: "Edit a prior message, creating a new branch",
+	Long:  `Editing a message never overwrites it; it creates a new branch starting from the edited message's parent so the original stays intact.`,
+	Args:  cobra.MinimumNArgs(3),
+	RunE:  runConversationEdit,
+}
+
+var rmCmd = &cobra.Command{
+	Use:   "rm <id>",
+	Short: "Delete a conversation",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConversationRemove,
+}
+
+func init() {
+	rootCmd.AddCommand(newCmd, replyCmd, viewCmd, editCmd, rmCmd)
+}
+
+func runConversationNew(cmd *cobra.Command, args []string) error {
+	instruction := strings.Join(args, " ")
+
+	p, err := detectProvider()
+	if err != nil {
+		return err
+	}
+
+	store, err := conversation.NewStore()
+	if err != nil {
+		return err
+	}
+
+	conv := store.New()
+	conv.AddMessage(conversation.Message{Role: "user", Content: instruction})
+
+	resp, err := generateFromHistory(context.Background(), p, conv)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to generate command: %v", err))
+		return err
+	}
+	conv.AddMessage(conversation.Message{Role: "assistant", Content: resp.Command, Provider: resp.Provider, Model: resp.Model})
+
+	if err := store.Save(conv); err != nil {
+		return err
+	}
+
+	fmt.Printf("Conversation %s\n%s\n", conv.ID, resp.Command)
+	return nil
+}
+
+func runConversationReply(cmd *cobra.Command, args []string) error {
+	id := args[0]
+	text := strings.Join(args[1:], " ")
+
+	store, err := conversation.NewStore()
+	if err != nil {
+		return err
+	}
+
+	conv, err := store.Load(id)
+	if err != nil {
+		return fmt.Errorf("loading conversation %s: %w", id, err)
+	}
+
+	leaf, ok := conv.Leaf()
+	parentID := ""
+	if ok {
+		parentID = leaf.ID
+	}
+	conv.AddMessage(conversation.Message{ParentID: parentID, Role: "user", Content: text})
+
+	p, err := detectProvider()
+	if err != nil {
+		return err
+	}
+
+	resp, err := generateFromHistory(context.Background(), p, conv)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to generate command: %v", err))
+		return err
+	}
+
+	newLeaf, _ := conv.Leaf()
+	conv.AddMessage(conversation.Message{ParentID: newLeaf.ID, Role: "assistant", Content: resp.Command, Provider: resp.Provider, Model: resp.Model})
+
+	if err := store.Save(conv); err != nil {
+		return err
+	}
+
+	fmt.Println(resp.Command)
+	return nil
+}
+
+func runConversationEdit(cmd *cobra.Command, args []string) error {
+	id, messageID := args[0], args[1]
+	text := strings.Join(args[2:], " ")
+
+	store, err := conversation.NewStore()
+	if err != nil {
+		return err
+	}
+
+	conv, err := store.Load(id)
+	if err != nil {
+		return fmt.Errorf("loading conversation %s: %w", id, err)
+	}
+
+	original, ok := conv.Find(messageID)
+	if !ok {
+		return fmt.Errorf("message %s not found in conversation %s", messageID, id)
+	}
+
+	branchPoint := conv.AddMessage(conversation.Message{ParentID: original.ParentID, Role: original.Role, Content: text})
+
+	p, err := detectProvider()
+	if err != nil {
+		return err
+	}
+
+	resp, err := generateFromHistory(context.Background(), p, conv)
+	if err != nil {
+		ui.PrintError(fmt.Sprintf("Failed to generate command: %v", err))
+		return err
+	}
+	conv.AddMessage(conversation.Message{ParentID: branchPoint.ID, Role: "assistant", Content: resp.Command, Provider: resp.Provider, Model: resp.Model})
+
+	if err := store.Save(conv); err != nil {
+		return err
+	}
+
+	fmt.Println(resp.Command)
+	return nil
+}
+
+func runConversationView(cmd *cobra.Command, args []string) error {
+	store, err := conversation.NewStore()
+	if err != nil {
+		return err
+	}
+
+	conv, err := store.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("loading conversation %s: %w", args[0], err)
+	}
+
+	leaf, ok := conv.Leaf()
+	if !ok {
+		fmt.Println("(empty conversation)")
+		return nil
+	}
+
+	for _, m := range conv.Branch(leaf.ID) {
+		fmt.Printf("[%s] %s: %s\n", m.ID, m.Role, m.Content)
+	}
+	return nil
+}
+
+func runConversationRemove(cmd *cobra.Command, args []string) error {
+	store, err := conversation.NewStore()
+	if err != nil {
+		return err
+	}
+	return store.Remove(args[0])
+}
+
+// detectProvider loads config and resolves the active provider, the same
+// way runCommand does.
+func detectProvider() (provider.Provider, error) {
+	cfg, err := cfgMgr.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.Verbose = verbose
+
+	registry := provider.NewRegistry(cfg, verbose)
+	p, err := registry.Detect()
+	if err != nil {
+		ui.PrintError(err.Error())
+		return nil, err
+	}
+	return p, nil
+}
+
+// generateFromHistory generates a command using the conversation's current
+// branch as context when the provider supports it, falling back to a
+// single-turn prompt built from just the latest user message.
+func generateFromHistory(ctx context.Context, p provider.Provider, conv *conversation.Conversation) (*provider.Response, error) {
+	leaf, ok := conv.Leaf()
+	if !ok {
+		return nil, fmt.Errorf("conversation has no messages")
+	}
+
+	if hp, ok := p.(provider.HistoryProvider); ok {
+		branch := conv.Branch(leaf.ID)
+		history := make([]provider.HistoryMessage, len(branch))
+		for i, m := range branch {
+			history[i] = provider.HistoryMessage{Role: m.Role, Content: m.Content}
+		}
+		return hp.GenerateWithHistory(ctx, history)
+	}
+
+	promptText, err := prompt.Build(leaf.Content, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return p.GenerateCommand(ctx, promptText)
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/REDFOX1899/ask-sh/internal/hooks"
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+	"github.com/REDFOX1899/ask-sh/internal/safety"
+	"github.com/REDFOX1899/ask-sh/internal/session"
+	"github.com/REDFOX1899/ask-sh/internal/ui"
+)
+
+// pastFailuresForFix caps how many similar past failures get folded into
+// a fix prompt as few-shot context, to keep it from growing unbounded.
+const pastFailuresForFix = 3
+
+// executeWithFixLoop runs command live in the execution UI, persists the
+// outcome to hist, and - on a non-zero exit the user asks the AI about -
+// re-prompts the model with the original instruction, the failed command,
+// its exit code, and a tail of its output, plus similar past failures as
+// few-shot context. ok is true only when the user accepted that offer, in
+// which case revised is the command to loop back into the confirm UI
+// with.
+func executeWithFixLoop(ctx context.Context, p provider.Provider, hist *session.History, instruction, command, providerName, modelName string) (revised string, ok bool, err error) {
+	started := time.Now()
+	mutated, hookErr := runHookPhase(ctx, hooks.PhasePreExecute, started, instruction, command, providerName, modelName, 0)
+	if hookErr != nil {
+		ui.PrintError(hookErr.Error())
+		return "", false, nil
+	}
+	command = mutated
+
+	result, runErr := ui.RunExecUI(command)
+	if runErr != nil {
+		return "", false, runErr
+	}
+
+	if _, hookErr := runHookPhase(ctx, hooks.PhasePostExecute, started, instruction, command, providerName, modelName, result.ExitCode); hookErr != nil {
+		ui.PrintError(hookErr.Error())
+	}
+
+	outcome := "executed"
+	if result.ExitCode != 0 {
+		outcome = "failed"
+	}
+	assessment := safety.AnalyzeCommand(command)
+	_ = hist.Append(session.Entry{
+		Prompt:     instruction,
+		Command:    command,
+		Provider:   providerName,
+		Model:      modelName,
+		RiskLevel:  safety.GetRiskLevelName(assessment.Level),
+		Outcome:    outcome,
+		ExitCode:   result.ExitCode,
+		StderrTail: result.Tail,
+	})
+
+	if result.Action != ui.ExecActionFix {
+		return "", false, nil
+	}
+
+	pastFailures, _ := hist.SimilarFailures(instruction, pastFailuresForFix)
+	fixPrompt := buildFixPrompt(instruction, command, result, pastFailures)
+
+	fmt.Println("Asking AI to fix the command...")
+	resp, genErr := p.GenerateCommand(ctx, fixPrompt)
+	if genErr != nil {
+		ui.PrintError(fmt.Sprintf("Failed to generate a fix: %v", genErr))
+		return "", false, nil
+	}
+	return resp.Command, true, nil
+}
+
+// buildFixPrompt assembles the raw prompt text sent to the model for a
+// fix, the same way explainCommand builds its own ad hoc prompt rather
+// than going through prompt.Build's command-generation template.
+func buildFixPrompt(instruction, command string, result ui.ExecResult, pastFailures []session.Entry) string {
+	var b strings.Builder
+	b.WriteString("The following shell command failed. Propose a corrected command that achieves the original instruction.\n\n")
+	fmt.Fprintf(&b, "Original instruction: %s\n", instruction)
+	fmt.Fprintf(&b, "Command that failed: %s\n", command)
+	fmt.Fprintf(&b, "Exit code: %d\n", result.ExitCode)
+	if result.Tail != "" {
+		fmt.Fprintf(&b, "Last output:\n%s\n", result.Tail)
+	}
+
+	if len(pastFailures) > 0 {
+		b.WriteString("\nSimilar past failures, for reference:\n")
+		for _, f := range pastFailures {
+			fmt.Fprintf(&b, "- instruction %q, command %q failed with exit %d: %s\n", f.Prompt, f.Command, f.ExitCode, f.StderrTail)
+		}
+	}
+
+	b.WriteString("\nReturn ONLY the corrected shell command, nothing else.\n")
+	return b.String()
+}
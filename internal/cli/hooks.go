@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/REDFOX1899/ask-sh/internal/audit"
+	"github.com/REDFOX1899/ask-sh/internal/hooks"
+)
+
+// hookPipeline and auditLog are initialized once in Execute and shared by
+// every command; a site with no ~/.x/hooks.yaml gets an empty Pipeline
+// that's a no-op at every phase.
+var (
+	hookPipeline *hooks.Pipeline
+	auditLog     *audit.Log
+)
+
+// loadHooks initializes hookPipeline and auditLog. Both are best-effort:
+// a hooks.yaml parse error is surfaced, but a missing audit log directory
+// never blocks the CLI from starting.
+func loadHooks() error {
+	path, err := hooks.DefaultPath()
+	if err != nil {
+		return err
+	}
+	hookPipeline, err = hooks.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if log, err := audit.Open(); err == nil {
+		auditLog = log
+	}
+	return nil
+}
+
+// runHookPhase runs every hook registered for phase, appending the result
+// to the audit log with elapsed since started. It returns the (possibly
+// hook-mutated) command and a non-nil error if a hook vetoed the phase.
+func runHookPhase(ctx context.Context, phase hooks.Phase, started time.Time, instruction, command, providerName, modelName string, exitCode int) (string, error) {
+	event := hooks.NewEvent(phase, instruction, command, providerName, modelName)
+	event.ExitCode = exitCode
+
+	mutated, decisions, err := hookPipeline.Run(ctx, event)
+	if err != nil {
+		return command, err
+	}
+
+	if auditLog != nil {
+		_ = auditLog.Append(audit.Entry{
+			Phase:       phase,
+			Instruction: instruction,
+			Command:     mutated,
+			Provider:    providerName,
+			Model:       modelName,
+			Duration:    time.Since(started),
+			ExitCode:    exitCode,
+			Decisions:   decisions,
+		})
+	}
+
+	for _, d := range decisions {
+		if d.Veto {
+			return mutated, fmt.Errorf("vetoed by %s hook: %s", phase, d.Reason)
+		}
+	}
+	return mutated, nil
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/mcpserver"
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+)
+
+// serveMCP switches `x serve` from its default OpenAI-compatible HTTP
+// server to a Model Context Protocol server over stdio.
+var serveMCP bool
+
+func init() {
+	serveCmd.Flags().BoolVar(&serveMCP, "mcp", false, "Run a Model Context Protocol server over stdio instead of the HTTP server")
+}
+
+// runMCPServe wires up a provider registry the same way every other
+// entry point does and hands it to mcpserver, which speaks MCP over
+// stdin/stdout until the client disconnects.
+func runMCPServe(cfg *config.Config) error {
+	registry := provider.NewRegistry(cfg, verbose)
+	if err := applyTransportFlags(registry); err != nil {
+		return err
+	}
+	return mcpserver.New(registry, cfg, os.Stdin, os.Stdout).Serve()
+}
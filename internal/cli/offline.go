@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+	"github.com/REDFOX1899/ask-sh/internal/provider/transport"
+)
+
+var (
+	offlineFlag string
+	recordFlag  string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&offlineFlag, "offline", "", "Replay provider responses from a cassette file instead of calling the network")
+	rootCmd.PersistentFlags().StringVar(&recordFlag, "record", "", "Record provider responses to a cassette file while running normally")
+}
+
+// applyTransportFlags points every provider in registry at the transport
+// implied by --offline/--record, if either was set. It's a no-op when
+// neither flag is set, leaving providers on transport.Default.
+func applyTransportFlags(registry *provider.Registry) error {
+	if offlineFlag != "" && recordFlag != "" {
+		return fmt.Errorf("--offline and --record are mutually exclusive")
+	}
+
+	var t transport.Transport
+	switch {
+	case offlineFlag != "":
+		replay, err := transport.LoadReplayTransport(offlineFlag)
+		if err != nil {
+			return fmt.Errorf("loading cassette %s: %w", offlineFlag, err)
+		}
+		t = replay
+	case recordFlag != "":
+		t = transport.NewRecordingTransport(transport.Default, recordFlag)
+	default:
+		return nil
+	}
+
+	for _, p := range registry.List() {
+		if setter, ok := p.(provider.TransportSetter); ok {
+			setter.SetTransport(t)
+		}
+	}
+	return nil
+}
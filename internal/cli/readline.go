@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// lineReader reads a single line at a time with basic readline-style
+// editing: backspace, and Up/Down to recall previous lines. It's seeded
+// from the REPL's persisted history so recall works across restarts, not
+// just within one session. On a non-terminal stdin (piped input, a
+// harness) it falls back to plain buffered line reading.
+type lineReader struct {
+	f       *os.File
+	raw     bool
+	scanner *bufio.Scanner
+	history []string
+	pos     int // index into history the next Up would recall; len(history) means "not recalling"
+}
+
+func newLineReader(f *os.File, history []string) *lineReader {
+	lr := &lineReader{f: f, history: append([]string{}, history...)}
+	lr.pos = len(lr.history)
+	if term.IsTerminal(int(f.Fd())) {
+		lr.raw = true
+	} else {
+		lr.scanner = bufio.NewScanner(f)
+	}
+	return lr
+}
+
+// AddHistory makes line recallable via Up for the rest of this session.
+func (lr *lineReader) AddHistory(line string) {
+	lr.history = append(lr.history, line)
+	lr.pos = len(lr.history)
+}
+
+// Close restores terminal state. It's a no-op in the non-terminal fallback
+// path and when raw mode is entered/restored per call, as it is here.
+func (lr *lineReader) Close() error {
+	return nil
+}
+
+// ReadLine prints prompt and reads one line of input.
+func (lr *lineReader) ReadLine(prompt string) (string, error) {
+	if !lr.raw {
+		fmt.Print(prompt)
+		if !lr.scanner.Scan() {
+			if err := lr.scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		}
+		return lr.scanner.Text(), nil
+	}
+	return lr.readLineRaw(prompt)
+}
+
+func (lr *lineReader) readLineRaw(prompt string) (string, error) {
+	fd := int(lr.f.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		// Raw mode isn't available (e.g. running under a harness with a
+		// pty-less stdin despite IsTerminal saying otherwise); fall back
+		// to plain line-buffered input for this one line.
+		fmt.Print(prompt)
+		reader := bufio.NewReader(lr.f)
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return "", io.EOF
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	defer term.Restore(fd, oldState)
+
+	fmt.Print(prompt)
+	var buf []rune
+	cur := lr.pos
+	savedLine := ""
+
+	redraw := func() {
+		fmt.Print("\r\x1b[K", prompt, string(buf))
+	}
+
+	in := bufio.NewReader(lr.f)
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Print("\r\n")
+			lr.pos = len(lr.history)
+			return string(buf), nil
+
+		case 3: // Ctrl+C
+			fmt.Print("\r\n")
+			return "", io.EOF
+
+		case 4: // Ctrl+D
+			if len(buf) == 0 {
+				fmt.Print("\r\n")
+				return "", io.EOF
+			}
+
+		case 127, 8: // Backspace
+			if len(buf) > 0 {
+				buf = buf[:len(buf)-1]
+				redraw()
+			}
+
+		case 27: // ESC: the start of an arrow-key escape sequence
+			b1, _, err1 := in.ReadRune()
+			b2, _, err2 := in.ReadRune()
+			if err1 != nil || err2 != nil || b1 != '[' {
+				continue
+			}
+			switch b2 {
+			case 'A': // Up
+				if cur == 0 {
+					continue
+				}
+				if cur == len(lr.history) {
+					savedLine = string(buf)
+				}
+				cur--
+				buf = []rune(lr.history[cur])
+				redraw()
+			case 'B': // Down
+				if cur >= len(lr.history) {
+					continue
+				}
+				cur++
+				if cur == len(lr.history) {
+					buf = []rune(savedLine)
+				} else {
+					buf = []rune(lr.history[cur])
+				}
+				redraw()
+			}
+
+		default:
+			if r >= 32 {
+				buf = append(buf, r)
+				fmt.Print(string(r))
+			}
+		}
+	}
+}
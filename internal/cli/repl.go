@@ -0,0 +1,432 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/REDFOX1899/ask-sh/internal/conversation"
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+	"github.com/REDFOX1899/ask-sh/internal/safety"
+	"github.com/REDFOX1899/ask-sh/internal/session"
+	"github.com/REDFOX1899/ask-sh/internal/ui"
+)
+
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Short: "Start an interactive session for natural-language shell commands",
+	Long: `repl is a persistent top-level loop, modeled on interactive tops like
+Agda's or Cryptol's: type a natural-language instruction to generate a
+command, or a slash-command to control the session. Running "x" with no
+arguments is shorthand for "x repl".
+
+Slash-commands:
+  /explain <cmd>         Explain a command without generating one
+  /refine <text>         Refine the last generated command
+  /undo                  Drop the last turn and go back to the one before it
+  /history               Show past prompt/command turns from ~/.x/history.jsonl
+  /risk <cmd>            Run the safety analyzer against an arbitrary command
+  /set provider <name>   Switch provider for the rest of the session
+  /set model <alias>     Switch to a named model alias for the rest of the session
+  /save <file>           Write the last generated command to a file
+  /load <file>           Load a command from a file as the "last command"
+  /exit                  Leave the REPL`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRepl()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replCmd)
+}
+
+// replState carries the bits of the session that slash-commands mutate:
+// which provider/model is active, the conversation the model uses for
+// follow-up context, and the last command generated, refined, or loaded.
+type replState struct {
+	providerName string
+	modelAlias   string
+	conv         *conversation.Conversation
+	lastCommand  string
+}
+
+func runRepl() error {
+	hist, err := session.Open()
+	if err != nil {
+		return fmt.Errorf("opening session history: %w", err)
+	}
+
+	prompts, err := hist.Prompts()
+	if err != nil {
+		return fmt.Errorf("reading session history: %w", err)
+	}
+
+	store, err := conversation.NewStore()
+	if err != nil {
+		return err
+	}
+	state := &replState{conv: store.New()}
+	ctx := context.Background()
+
+	fmt.Println("x repl - type an instruction, or /exit to quit (/history for prior commands)")
+
+	reader := newLineReader(os.Stdin, prompts)
+	defer reader.Close()
+
+	for {
+		line, err := reader.ReadLine("x> ")
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		reader.AddHistory(line)
+
+		if strings.HasPrefix(line, "/") {
+			handled, exit := handleSlashCommand(ctx, state, hist, line)
+			if exit {
+				return nil
+			}
+			if handled {
+				continue
+			}
+		}
+
+		if err := replTurn(ctx, state, hist, line); err != nil {
+			ui.PrintError(err.Error())
+		}
+	}
+}
+
+// handleSlashCommand processes one leading-slash meta-command. handled is
+// true if line was a recognized slash-command, so the caller won't also
+// treat it as a natural-language instruction. exit is true if the REPL
+// should terminate.
+func handleSlashCommand(ctx context.Context, state *replState, hist *session.History, line string) (handled bool, exit bool) {
+	fields := strings.Fields(line)
+	name := fields[0]
+	rest := strings.TrimSpace(strings.TrimPrefix(line, name))
+
+	switch name {
+	case "/exit", "/quit":
+		return true, true
+
+	case "/history":
+		entries, err := hist.All()
+		if err != nil {
+			ui.PrintError(err.Error())
+			return true, false
+		}
+		if len(entries) == 0 {
+			fmt.Println("(no history yet)")
+			return true, false
+		}
+		for _, e := range entries {
+			fmt.Printf("[%s] %s -> %s\n", e.Timestamp.Format("15:04:05"), e.Prompt, e.Command)
+		}
+		return true, false
+
+	case "/risk":
+		if rest == "" {
+			ui.PrintError("usage: /risk <command>")
+			return true, false
+		}
+		assessment := safety.AnalyzeCommand(rest)
+		fmt.Printf("%s: %s\n", safety.GetRiskLevelName(assessment.Level), rest)
+		for _, w := range assessment.Warnings {
+			fmt.Println("  -", w)
+		}
+		return true, false
+
+	case "/explain":
+		if rest == "" {
+			ui.PrintError("usage: /explain <command>")
+			return true, false
+		}
+		p, err := resolveReplProvider(state)
+		if err != nil {
+			ui.PrintError(err.Error())
+			return true, false
+		}
+		explanation, err := explainCommand(ctx, p, rest)
+		if err != nil {
+			ui.PrintError(err.Error())
+			return true, false
+		}
+		fmt.Println(explanation)
+		return true, false
+
+	case "/refine":
+		if state.lastCommand == "" {
+			ui.PrintError("no command to refine yet")
+			return true, false
+		}
+		if rest == "" {
+			ui.PrintError("usage: /refine <text>")
+			return true, false
+		}
+		p, err := resolveReplProvider(state)
+		if err != nil {
+			ui.PrintError(err.Error())
+			return true, false
+		}
+		resp, err := p.RefineCommand(ctx, state.lastCommand, rest)
+		if err != nil {
+			ui.PrintError(err.Error())
+			return true, false
+		}
+		state.lastCommand = resp.Command
+		fmt.Println(resp.Command)
+		return true, false
+
+	case "/undo":
+		leaf, ok := state.conv.Leaf()
+		if !ok {
+			ui.PrintError("nothing to undo")
+			return true, false
+		}
+		// A turn is a user message plus, when generation succeeded, the
+		// assistant reply under it; the message before that turn becomes
+		// the new leaf.
+		start := leaf
+		if leaf.Role == "assistant" {
+			start, _ = state.conv.Find(leaf.ParentID)
+		}
+		if prev, ok := state.conv.Find(start.ParentID); ok {
+			state.conv.AddMessage(conversation.Message{ParentID: prev.ParentID, Role: prev.Role, Content: prev.Content, Provider: prev.Provider, Model: prev.Model})
+			if prev.Role == "assistant" {
+				state.lastCommand = prev.Content
+			}
+		} else {
+			state.conv = &conversation.Conversation{ID: state.conv.ID}
+			state.lastCommand = ""
+		}
+		fmt.Println("Undid last turn")
+		return true, false
+
+	case "/set":
+		parts := strings.Fields(rest)
+		if len(parts) != 2 {
+			ui.PrintError("usage: /set provider <name> | /set model <alias>")
+			return true, false
+		}
+		switch parts[0] {
+		case "provider":
+			state.providerName = parts[1]
+			state.modelAlias = ""
+			fmt.Printf("provider set to %s\n", parts[1])
+		case "model":
+			state.modelAlias = parts[1]
+			fmt.Printf("model set to %s\n", parts[1])
+		default:
+			ui.PrintError("usage: /set provider <name> | /set model <alias>")
+		}
+		return true, false
+
+	case "/save":
+		if rest == "" {
+			ui.PrintError("usage: /save <file>")
+			return true, false
+		}
+		if state.lastCommand == "" {
+			ui.PrintError("no command to save yet")
+			return true, false
+		}
+		if err := os.WriteFile(rest, []byte(state.lastCommand+"\n"), 0644); err != nil {
+			ui.PrintError(err.Error())
+		}
+		return true, false
+
+	case "/load":
+		if rest == "" {
+			ui.PrintError("usage: /load <file>")
+			return true, false
+		}
+		data, err := os.ReadFile(rest)
+		if err != nil {
+			ui.PrintError(err.Error())
+			return true, false
+		}
+		state.lastCommand = strings.TrimSpace(string(data))
+		fmt.Println(state.lastCommand)
+		return true, false
+	}
+
+	return false, false
+}
+
+// resolveReplProvider picks the provider for the current turn, honoring
+// whatever /set provider or /set model last chose, falling back to the
+// same auto-detection a one-shot "x" invocation uses.
+func resolveReplProvider(state *replState) (provider.Provider, error) {
+	return resolveProviderFor(state.providerName, state.modelAlias)
+}
+
+// resolveProviderFor picks a provider by name or model alias, falling back
+// to the same auto-detection a one-shot "x" invocation uses. It backs both
+// "x repl"'s /set provider|model and "x console"'s /provider and /model.
+func resolveProviderFor(providerName, modelAlias string) (provider.Provider, error) {
+	cfg, err := cfgMgr.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.Verbose = verbose
+
+	registry := provider.NewRegistry(cfg, verbose)
+	if err := applyTransportFlags(registry); err != nil {
+		return nil, err
+	}
+
+	if modelAlias != "" {
+		return registry.ResolveModel(modelAlias)
+	}
+	if providerName != "" {
+		return registry.Get(providerName)
+	}
+	return registry.Detect()
+}
+
+// replTurn generates a command for a natural-language instruction, feeding
+// the session's conversation for context so follow-ups like "now make it
+// recursive" read naturally, then drives the same confirm/edit/refine/
+// explain TUI loop a one-shot "x" invocation uses before recording the
+// outcome to history.
+func replTurn(ctx context.Context, state *replState, hist *session.History, instruction string) error {
+	p, err := resolveReplProvider(state)
+	if err != nil {
+		return err
+	}
+
+	leaf, hasLeaf := state.conv.Leaf()
+	parentID := ""
+	if hasLeaf {
+		parentID = leaf.ID
+	}
+	state.conv.AddMessage(conversation.Message{ParentID: parentID, Role: "user", Content: instruction})
+
+	resp, err := generateFromHistory(ctx, p, state.conv)
+	if err != nil {
+		return fmt.Errorf("generating command: %w", err)
+	}
+	newLeaf, _ := state.conv.Leaf()
+	state.conv.AddMessage(conversation.Message{ParentID: newLeaf.ID, Role: "assistant", Content: resp.Command, Provider: resp.Provider, Model: resp.Model})
+	state.lastCommand = resp.Command
+
+	if _, err := safety.Gate(resp.Command, resp.Danger); err != nil {
+		ui.PrintError(fmt.Sprintf("Refusing to run %q: %v", resp.Command, err))
+		return nil
+	}
+
+	record := func(command, outcome string) {
+		assessment := safety.AnalyzeCommand(command)
+		_ = hist.Append(session.Entry{
+			Prompt:    instruction,
+			Command:   command,
+			Provider:  resp.Provider,
+			Model:     resp.Model,
+			RiskLevel: safety.GetRiskLevelName(assessment.Level),
+			Outcome:   outcome,
+		})
+	}
+
+	command := resp.Command
+	for {
+		result, err := ui.RunTUI(command, resp.Provider, resp.Model)
+		if err != nil {
+			return err
+		}
+
+		switch result.Action {
+		case ui.ActionExecute:
+			command = result.Command
+			state.lastCommand = command
+			revised, askedFix, err := executeWithFixLoop(ctx, p, hist, instruction, command, resp.Provider, resp.Model)
+			if err != nil {
+				return err
+			}
+			if askedFix {
+				command = revised
+				state.lastCommand = command
+				continue
+			}
+			return nil
+
+		case ui.ActionCancel:
+			record(command, "cancelled")
+			fmt.Println("Command execution cancelled")
+			return nil
+
+		case ui.ActionEdit:
+			command = result.Command
+			state.lastCommand = command
+
+		case ui.ActionRefine:
+			refined, err := p.RefineCommand(ctx, command, result.RefinementQuery)
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Failed to refine command: %v", err))
+				continue
+			}
+			command = refined.Command
+			state.lastCommand = command
+
+		case ui.ActionExplainRisk:
+			printRiskExplanation(safety.AnalyzeCommand(command))
+
+		case ui.ActionDownloadReview:
+			review, err := safety.ReviewDownload(command)
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("Download and review failed: %v", err))
+			} else {
+				printDownloadReview(review)
+			}
+
+		case ui.ActionExplain:
+			result, err := explainCommandStreaming(ctx, p, command, resp.Provider, resp.Model)
+			if err != nil {
+				return err
+			}
+			switch result.Action {
+			case ui.ActionExecute:
+				command = result.Command
+				state.lastCommand = command
+				revised, askedFix, err := executeWithFixLoop(ctx, p, hist, instruction, command, resp.Provider, resp.Model)
+				if err != nil {
+					return err
+				}
+				if askedFix {
+					command = revised
+					state.lastCommand = command
+					continue
+				}
+				return nil
+			case ui.ActionCancel:
+				record(command, "cancelled")
+				fmt.Println("Command execution cancelled")
+				return nil
+			case ui.ActionEdit:
+				command = result.Command
+				state.lastCommand = command
+			case ui.ActionRefine:
+				refined, err := p.RefineCommand(ctx, command, result.RefinementQuery)
+				if err != nil {
+					ui.PrintError(fmt.Sprintf("Failed to refine command: %v", err))
+				} else {
+					command = refined.Command
+					state.lastCommand = command
+				}
+			}
+		}
+	}
+}
@@ -4,20 +4,33 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/REDFOX1899/ask-sh/internal/config"
+	envcontext "github.com/REDFOX1899/ask-sh/internal/context"
+	"github.com/REDFOX1899/ask-sh/internal/dryrun"
+	"github.com/REDFOX1899/ask-sh/internal/hooks"
 	"github.com/REDFOX1899/ask-sh/internal/prompt"
 	"github.com/REDFOX1899/ask-sh/internal/provider"
+	"github.com/REDFOX1899/ask-sh/internal/safety"
+	"github.com/REDFOX1899/ask-sh/internal/session"
 	"github.com/REDFOX1899/ask-sh/internal/ui"
 )
 
 var (
-	verbose bool
-	cfgMgr  *config.Manager
+	verbose     bool
+	modelFlag   string
+	dryRun      bool
+	jsonOutput  bool
+	stdinBatch  bool
+	yesFlag     bool
+	explainOnly bool
+	refineQuery string
+	noContext   bool
+	cfgMgr      *config.Manager
 )
 
 // rootCmd represents the base command
@@ -25,14 +38,18 @@ var rootCmd = &cobra.Command{
 	Use:   "x [instruction]",
 	Short: "Natural language shell command executor",
 	Long: `x converts natural language instructions into shell commands.
-It supports OpenAI, Anthropic, Gemini, and Ollama API providers.
+It supports OpenAI, Anthropic, Gemini, Ollama, and gRPC-backed local
+providers.
 
-Set one of: OPENAI_API_KEY, ANTHROPIC_API_KEY, GEMINI_API_KEY, or OLLAMA_MODEL`,
+Set one of: OPENAI_API_KEY, ANTHROPIC_API_KEY, GEMINI_API_KEY,
+OLLAMA_MODEL, or GRPC_PROVIDER_ADDR
+
+Run with no arguments to start an interactive session (equivalent to "x repl").`,
 	Example: `  x get all the git branches
   x list all files modified in the last 7 days
   x show disk usage of current directory
   x count lines in all python files`,
-	Args:          cobra.MinimumNArgs(1),
+	Args:          cobra.ArbitraryArgs,
 	RunE:          runCommand,
 	SilenceUsage:  true,
 	SilenceErrors: true,
@@ -41,6 +58,14 @@ Set one of: OPENAI_API_KEY, ANTHROPIC_API_KEY, GEMINI_API_KEY, or OLLAMA_MODEL`,
 func init() {
 	// Persistent flags
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable debug output")
+	rootCmd.PersistentFlags().StringVar(&modelFlag, "model", "", "Use a named model alias from ~/.x/models/")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Preview the generated command's filesystem effect instead of running it")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Print the generated command (and any batch output) as JSON instead of plain text")
+	rootCmd.PersistentFlags().BoolVar(&stdinBatch, "stdin", false, "Read one instruction per line (or a JSON array) from stdin and emit one JSON result per line")
+	rootCmd.PersistentFlags().BoolVarP(&yesFlag, "yes", "y", false, "Skip the interactive confirm/edit UI and execute the generated command directly")
+	rootCmd.PersistentFlags().BoolVar(&explainOnly, "explain-only", false, "Print an explanation of the generated command instead of running it")
+	rootCmd.PersistentFlags().StringVar(&refineQuery, "refine", "", "Refine the generated command with this follow-up query before using it")
+	rootCmd.PersistentFlags().BoolVar(&noContext, "no-context", false, "Skip gathering shell/OS/git environment context for the prompt")
 
 	// Add subcommands
 	rootCmd.AddCommand(versionCmd)
@@ -55,10 +80,40 @@ func Execute() error {
 		return fmt.Errorf("failed to initialize config: %w", err)
 	}
 
+	if path, err := safety.DefaultPath(); err == nil {
+		if rs, err := safety.LoadRuleSet(path); err == nil {
+			safety.SetDefault(rs)
+		}
+	}
+
+	if err := loadHooks(); err != nil {
+		return fmt.Errorf("failed to load hooks: %w", err)
+	}
+
 	return rootCmd.Execute()
 }
 
 func runCommand(cmd *cobra.Command, args []string) error {
+	if stdinBatch {
+		cfg, err := cfgMgr.Load()
+		if err != nil {
+			ui.PrintError(fmt.Sprintf("Failed to load config: %v", err))
+			return err
+		}
+		cfg.Verbose = verbose
+
+		registry := provider.NewRegistry(cfg, verbose)
+		if err := applyTransportFlags(registry); err != nil {
+			ui.PrintError(err.Error())
+			return err
+		}
+		return runStdinBatch(cfg, registry)
+	}
+
+	if len(args) == 0 {
+		return runRepl()
+	}
+
 	// Combine all arguments into instruction
 	instruction := strings.Join(args, " ")
 
@@ -76,7 +131,12 @@ func runCommand(cmd *cobra.Command, args []string) error {
 
 	// Create provider registry and detect provider
 	registry := provider.NewRegistry(cfg, verbose)
-	p, err := registry.Detect()
+	if err := applyTransportFlags(registry); err != nil {
+		ui.PrintError(err.Error())
+		return err
+	}
+
+	p, promptTemplate, err := resolveProvider(registry, cfg)
 	if err != nil {
 		ui.PrintError(err.Error())
 		return err
@@ -86,17 +146,20 @@ func runCommand(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "DEBUG: Using API provider: %s\n", p.Name())
 	}
 
-	// Build prompt
-	promptText := prompt.Build(instruction)
-
-	// Generate command
 	ctx := context.Background()
-	resp, err := p.GenerateCommand(ctx, promptText)
+	resp, err := generateAndGate(ctx, p, promptTemplate, instruction, cfg)
 	if err != nil {
-		ui.PrintError(fmt.Sprintf("Failed to generate command: %v", err))
+		ui.PrintError(err.Error())
 		return err
 	}
 
+	if resp.RequiresSudo {
+		fmt.Fprintln(os.Stderr, "Note: the model believes this command requires sudo")
+	}
+	if resp.Danger == "medium" || resp.Danger == "high" {
+		fmt.Fprintf(os.Stderr, "Note: the model assessed this command as %s risk\n", resp.Danger)
+	}
+
 	// Save working model to config
 	if err := cfgMgr.SaveWorkingModel(resp.Provider, resp.Model); err != nil {
 		if verbose {
@@ -106,11 +169,147 @@ func runCommand(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "DEBUG: Saved working model: %s\n", resp.Model)
 	}
 
+	if dryRun {
+		return printDryRun(resp.Command)
+	}
+
+	hist, err := session.Open()
+	if err != nil {
+		return fmt.Errorf("opening session history: %w", err)
+	}
+
+	if jsonOutput || yesFlag || explainOnly || refineQuery != "" {
+		return runSingleShot(ctx, p, hist, instruction, resp)
+	}
+
 	// Run interactive TUI loop
-	return runInteractiveLoop(ctx, p, resp.Command, resp.Provider, resp.Model)
+	return runInteractiveLoop(ctx, p, instruction, resp.Command, resp.Provider, resp.Model, hist)
 }
 
-func runInteractiveLoop(ctx context.Context, p provider.Provider, command, providerName, modelName string) error {
+// resolveProvider picks the provider for one instruction the same way for
+// every non-interactive and interactive entry point: an explicit --model
+// alias if given, otherwise whatever the registry auto-detects.
+func resolveProvider(registry *provider.Registry, cfg *config.Config) (provider.Provider, string, error) {
+	if modelFlag != "" {
+		p, err := registry.ResolveModel(modelFlag)
+		if err != nil {
+			return nil, "", err
+		}
+		return p, cfg.Models[modelFlag].PromptTemplate, nil
+	}
+
+	p, err := registry.Detect()
+	if err != nil {
+		return nil, "", err
+	}
+	return p, "", nil
+}
+
+// buildEnvContext renders the shell/OS/git environment context that's
+// spliced into the prompt template, honoring --no-context. It's called
+// through generateAndGate, so both the single-instruction path and the
+// --stdin batch path get it - the HTTP, conversation, and MCP entry
+// points pass an empty context to prompt.Build instead.
+func buildEnvContext(instruction string, cfg *config.Config) string {
+	if noContext {
+		return ""
+	}
+	return envcontext.GetContext(instruction, cfg).Format(cfg.Context.MaxBytes)
+}
+
+// generateAndGate builds the prompt for instruction, runs it through p with
+// the pre/post-generate hooks, and gates the resulting command through
+// safety.Gate. It's the generation step shared by the interactive CLI path
+// and the non-interactive (--json/--yes/--stdin) batch paths, so a hook
+// veto or a denied command is caught identically by both.
+func generateAndGate(ctx context.Context, p provider.Provider, promptTemplate, instruction string, cfg *config.Config) (*provider.Response, error) {
+	promptText, err := prompt.Build(instruction, promptTemplate, buildEnvContext(instruction, cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build prompt: %w", err)
+	}
+
+	started := time.Now()
+	if _, err := runHookPhase(ctx, hooks.PhasePreGenerate, started, instruction, "", p.Name(), modelFlag, 0); err != nil {
+		return nil, err
+	}
+
+	resp, err := generateCommand(ctx, p, promptText, verbose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate command: %w", err)
+	}
+
+	mutated, err := runHookPhase(ctx, hooks.PhasePostGenerate, started, instruction, resp.Command, resp.Provider, resp.Model, 0)
+	if err != nil {
+		return nil, err
+	}
+	resp.Command = mutated
+
+	if _, err := safety.Gate(resp.Command, resp.Danger); err != nil {
+		return nil, fmt.Errorf("refusing to run %q: %w", resp.Command, err)
+	}
+
+	return resp, nil
+}
+
+// printDryRun previews the generated command's filesystem effect and
+// prints it instead of running the interactive loop, for `x --dry-run`.
+func printDryRun(command string) error {
+	fmt.Println(command)
+
+	assessment := safety.AnalyzeCommand(command)
+	fmt.Println("classification:", safety.GetRiskLevelName(assessment.Level))
+
+	result, err := dryrun.Preview(command)
+	if err == nil && !result.Supported {
+		result, err = dryrun.Trace(command)
+	}
+	if err != nil {
+		return fmt.Errorf("dry run failed: %w", err)
+	}
+
+	fmt.Println(result.Summary())
+	for _, line := range result.Tree() {
+		fmt.Println(line)
+	}
+	for _, note := range result.Notes {
+		fmt.Println("note:", note)
+	}
+	return nil
+}
+
+// printRiskExplanation prints the full detail behind every rule assessment
+// matched, for `i` in the confirm TUI - the warning box only shows the
+// short description, not the rule ID, category, or doc URL.
+func printRiskExplanation(assessment safety.RiskAssessment) {
+	if len(assessment.Hits) == 0 {
+		fmt.Println("No safety rules matched this command.")
+		return
+	}
+
+	fmt.Println("Matched safety rules:")
+	for _, hit := range assessment.Hits {
+		fmt.Printf("\n[%s] %s (%s, %s)\n", hit.RuleID, hit.Description, hit.Category, safety.GetRiskLevelName(hit.Level))
+		if hit.Suggestion != "" {
+			fmt.Printf("  suggestion: %s\n", hit.Suggestion)
+		}
+		if hit.DocURL != "" {
+			fmt.Printf("  docs: %s\n", hit.DocURL)
+		}
+	}
+}
+
+// printDownloadReview prints where a NETWORK-MUTATING command's payload was
+// saved and what the safety analyzer makes of its contents, for `w` in the
+// confirm TUI.
+func printDownloadReview(review *safety.DownloadReview) {
+	fmt.Printf("Downloaded %s to %s without executing it.\n", review.URL, review.Path)
+	fmt.Printf("Classification of downloaded contents: %s\n", safety.GetRiskLevelName(review.Assessment.Level))
+	for _, warning := range review.Assessment.Warnings {
+		fmt.Println("  •", warning)
+	}
+}
+
+func runInteractiveLoop(ctx context.Context, p provider.Provider, instruction, command, providerName, modelName string, hist *session.History) error {
 	for {
 		// Run TUI
 		result, err := ui.RunTUI(command, providerName, modelName)
@@ -120,8 +319,16 @@ func runInteractiveLoop(ctx context.Context, p provider.Provider, command, provi
 
 		switch result.Action {
 		case ui.ActionExecute:
-			// Execute the command
-			return executeShellCommand(result.Command)
+			command = result.Command
+			revised, askedFix, err := executeWithFixLoop(ctx, p, hist, instruction, command, providerName, modelName)
+			if err != nil {
+				return err
+			}
+			if askedFix {
+				command = revised
+				continue
+			}
+			return nil
 
 		case ui.ActionCancel:
 			fmt.Println("Command execution cancelled")
@@ -143,18 +350,23 @@ func runInteractiveLoop(ctx context.Context, p provider.Provider, command, provi
 			command = resp.Command
 			continue
 
-		case ui.ActionExplain:
-			// Get explanation and show TUI with explanation
-			fmt.Println("Getting explanation...")
-			explanation, err := p.ExplainCommand(ctx, command)
+		case ui.ActionExplainRisk:
+			printRiskExplanation(safety.AnalyzeCommand(command))
+			continue
+
+		case ui.ActionDownloadReview:
+			review, err := safety.ReviewDownload(command)
 			if err != nil {
-				ui.PrintError(fmt.Sprintf("Failed to explain command: %v", err))
-				// Continue without explanation
+				ui.PrintError(fmt.Sprintf("Download and review failed: %v", err))
 				continue
 			}
+			printDownloadReview(review)
+			continue
 
-			// Run TUI with explanation
-			result, err := ui.RunTUIWithExplanation(command, explanation, providerName, modelName)
+		case ui.ActionExplain:
+			// Show the explanation view immediately and stream the
+			// explanation into it live.
+			result, err := explainCommandStreaming(ctx, p, command, providerName, modelName)
 			if err != nil {
 				return err
 			}
@@ -162,7 +374,16 @@ func runInteractiveLoop(ctx context.Context, p provider.Provider, command, provi
 			// Handle the result from explanation view
 			switch result.Action {
 			case ui.ActionExecute:
-				return executeShellCommand(result.Command)
+				command = result.Command
+				revised, askedFix, err := executeWithFixLoop(ctx, p, hist, instruction, command, providerName, modelName)
+				if err != nil {
+					return err
+				}
+				if askedFix {
+					command = revised
+					continue
+				}
+				return nil
 			case ui.ActionCancel:
 				fmt.Println("Command execution cancelled")
 				return nil
@@ -188,17 +409,159 @@ func runInteractiveLoop(ctx context.Context, p provider.Provider, command, provi
 	}
 }
 
-func executeShellCommand(command string) error {
-	// Use the user's shell to execute the command
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/sh"
+// generateCommand generates a command, printing an incremental preview of
+// the raw model output as it arrives when the provider supports streaming.
+// It falls back to the buffered GenerateCommand on any streaming failure.
+func generateCommand(ctx context.Context, p provider.Provider, promptText string, verbose bool) (*provider.Response, error) {
+	if stp, ok := p.(provider.StructuredProvider); ok {
+		resp, err := stp.GenerateStructuredCommand(ctx, promptText)
+		if err == nil {
+			return resp, nil
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: Structured generation failed (%v), falling back\n", err)
+		}
+	}
+
+	sp, ok := p.(provider.StreamingProvider)
+	if !ok {
+		return p.GenerateCommand(ctx, promptText)
+	}
+
+	tokens, err := sp.StreamCommand(ctx, promptText)
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "DEBUG: Streaming unavailable (%v), falling back to buffered generation\n", err)
+		}
+		return p.GenerateCommand(ctx, promptText)
+	}
+
+	fmt.Print("Generating: ")
+	var out strings.Builder
+	for tok := range tokens {
+		if tok.Err != nil {
+			fmt.Println()
+			if verbose {
+				fmt.Fprintf(os.Stderr, "DEBUG: Stream failed (%v), falling back to buffered generation\n", tok.Err)
+			}
+			return p.GenerateCommand(ctx, promptText)
+		}
+		if tok.Text != "" {
+			fmt.Print(tok.Text)
+			out.WriteString(tok.Text)
+		}
+	}
+	fmt.Println()
+
+	command := strings.TrimSpace(out.String())
+	if command == "" {
+		return p.GenerateCommand(ctx, promptText)
+	}
+
+	model := p.Name()
+	if mn, ok := p.(interface{ PrimaryModel() string }); ok {
+		model = mn.PrimaryModel()
+	}
+
+	return &provider.Response{Command: command, Model: model, Provider: p.Name()}, nil
+}
+
+// buildExplainPrompt is the raw prompt sent to the model to explain
+// command, shared by the buffered and TUI-streaming explain paths so the
+// wording only lives in one place.
+func buildExplainPrompt(command string) string {
+	return fmt.Sprintf(`Explain this shell command in simple terms. Break down each flag and option.
+Keep it concise but educational. Format as a brief explanation followed by a breakdown of flags.
+
+Command: %s
+
+Explanation:`, command)
+}
+
+// explainCommand streams the explanation live when the provider supports
+// it, falling back to the buffered ExplainCommand otherwise.
+func explainCommand(ctx context.Context, p provider.Provider, command string) (string, error) {
+	sp, ok := p.(provider.StreamingProvider)
+	if !ok {
+		return p.ExplainCommand(ctx, command)
 	}
 
-	cmd := exec.Command(shell, "-c", command)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	tokens, err := sp.StreamCommand(ctx, buildExplainPrompt(command))
+	if err != nil {
+		return p.ExplainCommand(ctx, command)
+	}
+
+	var out strings.Builder
+	for tok := range tokens {
+		if tok.Err != nil {
+			return p.ExplainCommand(ctx, command)
+		}
+		if tok.Text != "" {
+			fmt.Print(tok.Text)
+			out.WriteString(tok.Text)
+		}
+	}
+	fmt.Println()
 
-	return cmd.Run()
+	explanation := strings.TrimSpace(out.String())
+	if explanation == "" {
+		return p.ExplainCommand(ctx, command)
+	}
+	return explanation, nil
+}
+
+// explainCommandStreaming shows the confirm TUI's explanation view and
+// streams the explanation into it live, token by token, instead of
+// blocking on the full response first. The user can interrupt a long
+// explanation with esc/ctrl+c, which cancels streamCtx and stops the
+// in-flight request rather than just hiding a result that keeps
+// generating in the background.
+func explainCommandStreaming(ctx context.Context, p provider.Provider, command, providerName, modelName string) (ui.Result, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks := make(chan ui.ExplainChunk)
+	go func() {
+		defer close(chunks)
+
+		sp, ok := p.(provider.StreamingProvider)
+		if !ok {
+			sendBufferedExplanation(streamCtx, p, command, chunks)
+			return
+		}
+
+		tokens, err := sp.StreamCommand(streamCtx, buildExplainPrompt(command))
+		if err != nil {
+			sendBufferedExplanation(streamCtx, p, command, chunks)
+			return
+		}
+
+		for tok := range tokens {
+			if tok.Err != nil {
+				// Partial text has already reached the TUI, so falling
+				// back to a buffered re-request here would duplicate or
+				// contradict what's on screen; just report the failure.
+				chunks <- ui.ExplainChunk{Err: tok.Err}
+				return
+			}
+			if tok.Text != "" {
+				chunks <- ui.ExplainChunk{Text: tok.Text}
+			}
+		}
+		chunks <- ui.ExplainChunk{Done: true}
+	}()
+
+	return ui.RunTUIStreamingExplanation(command, providerName, modelName, chunks, cancel)
+}
+
+// sendBufferedExplanation is the streaming path's fallback for providers
+// (or failed stream setups) that only support the buffered ExplainCommand:
+// it delivers the whole explanation as a single chunk.
+func sendBufferedExplanation(ctx context.Context, p provider.Provider, command string, chunks chan<- ui.ExplainChunk) {
+	explanation, err := p.ExplainCommand(ctx, command)
+	if err != nil {
+		chunks <- ui.ExplainChunk{Err: err}
+		return
+	}
+	chunks <- ui.ExplainChunk{Text: explanation, Done: true}
 }
@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/prompt"
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+)
+
+var servePort int
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Start an OpenAI-compatible HTTP server for command generation",
+	Long: `serve starts a local HTTP server exposing /v1/chat/completions and
+/v1/models, so editor plugins, shell hooks, or CI scripts can request
+generated commands through the same provider-agnostic detection and
+fallback logic as the CLI, without re-implementing it themselves.
+
+Send the "x-shell-mode: true" header to have the last user message run
+through x's own prompt template instead of being sent to the model
+verbatim.
+
+With --mcp, serve instead runs a Model Context Protocol server over
+stdio, exposing generate_command/refine_command/explain_command/
+execute_command as tools for clients like Claude Desktop or an editor's
+MCP integration.`,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().IntVar(&servePort, "port", 8080, "Port to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// chatMessage mirrors the OpenAI chat message shape.
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatCompletionRequest mirrors enough of the OpenAI
+// /v1/chat/completions request body to be usable by existing SDKs.
+type chatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type chatCompletionChoice struct {
+	Index        int          `json:"index"`
+	Message      *chatMessage `json:"message,omitempty"`
+	Delta        *chatMessage `json:"delta,omitempty"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+type chatCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []chatCompletionChoice `json:"choices"`
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	cfg, err := cfgMgr.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.Verbose = verbose
+
+	if serveMCP {
+		return runMCPServe(cfg)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chat/completions", handleChatCompletions(cfg))
+	mux.HandleFunc("/v1/models", handleModels(cfg))
+
+	addr := fmt.Sprintf(":%d", servePort)
+	fmt.Printf("x serve listening on %s (POST /v1/chat/completions)\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+func handleChatCompletions(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req chatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		instruction := lastUserMessage(req.Messages)
+		if instruction == "" {
+			http.Error(w, "no user message in request", http.StatusBadRequest)
+			return
+		}
+
+		promptText := instruction
+		if r.Header.Get("x-shell-mode") == "true" {
+			built, err := prompt.Build(instruction, "", "")
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to build prompt: %v", err), http.StatusInternalServerError)
+				return
+			}
+			promptText = built
+		}
+
+		registry := provider.NewRegistry(cfg, cfg.Verbose)
+		p, err := registry.Detect()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		id := fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+		if req.Stream {
+			streamChatCompletion(w, r.Context(), p, promptText, id)
+			return
+		}
+
+		resp, err := p.GenerateCommand(r.Context(), promptText)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("generation failed: %v", err), http.StatusBadGateway)
+			return
+		}
+
+		finishReason := "stop"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(chatCompletionResponse{
+			ID:      id,
+			Object:  "chat.completion",
+			Created: time.Now().Unix(),
+			Model:   resp.Model,
+			Choices: []chatCompletionChoice{
+				{
+					Index:        0,
+					Message:      &chatMessage{Role: "assistant", Content: resp.Command},
+					FinishReason: &finishReason,
+				},
+			},
+		})
+	}
+}
+
+// streamChatCompletion writes the response as SSE chunks in the OpenAI
+// streaming format, falling back to a single chunk when the provider
+// doesn't support token-by-token streaming.
+func streamChatCompletion(w http.ResponseWriter, ctx context.Context, p provider.Provider, promptText, id string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, canFlush := w.(http.Flusher)
+
+	writeChunk := func(content string, finishReason *string) {
+		chunk := chatCompletionResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   p.Name(),
+			Choices: []chatCompletionChoice{
+				{Index: 0, Delta: &chatMessage{Content: content}, FinishReason: finishReason},
+			},
+		}
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if canFlush {
+			flusher.Flush()
+		}
+	}
+
+	sp, ok := p.(provider.StreamingProvider)
+	if !ok {
+		resp, err := p.GenerateCommand(ctx, promptText)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("generation failed: %v", err), http.StatusBadGateway)
+			return
+		}
+		finishReason := "stop"
+		writeChunk(resp.Command, &finishReason)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		if canFlush {
+			flusher.Flush()
+		}
+		return
+	}
+
+	tokens, err := sp.StreamCommand(ctx, promptText)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("generation failed: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	for tok := range tokens {
+		if tok.Err != nil {
+			break
+		}
+		if tok.Text != "" {
+			writeChunk(tok.Text, nil)
+		}
+		if tok.Done {
+			finishReason := "stop"
+			writeChunk("", &finishReason)
+		}
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if canFlush {
+		flusher.Flush()
+	}
+}
+
+func handleModels(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := make([]map[string]string, 0, len(cfg.Models)+1)
+		data = append(data, map[string]string{"id": "auto", "object": "model"})
+		for alias := range cfg.Models {
+			data = append(data, map[string]string{"id": alias, "object": "model"})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"object": "list",
+			"data":   data,
+		})
+	}
+}
+
+func lastUserMessage(messages []chatMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return strings.TrimSpace(messages[i].Content)
+		}
+	}
+	return ""
+}
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds all application configuration
@@ -28,18 +31,55 @@ type Config struct {
 	OllamaModel string `mapstructure:"ollama_model"`
 	OllamaHost  string `mapstructure:"ollama_host"`
 
+	// GRPCProviderAddr is the host:port of a local inference backend
+	// implementing provider.v1.LocalProvider (see
+	// proto/provider/v1/provider.proto), e.g. "localhost:50051".
+	GRPCProviderAddr string `mapstructure:"grpc_provider_addr"`
+
 	// General settings
 	Verbose bool `mapstructure:"verbose"`
 	Timeout int  `mapstructure:"timeout"`
+
+	// Named model aliases loaded from ~/.x/models/*.yaml, keyed by filename
+	// (without extension).
+	Models map[string]ModelConfig `mapstructure:"-"`
+
+	// Context controls what environment context is gathered and injected
+	// into the prompt (see internal/context).
+	Context ContextConfig `mapstructure:"context"`
+}
+
+// ContextConfig controls the internal/context environment-context
+// collector: which sections to gather (Include/Exclude, by section name:
+// "shell", "os", "cwd", "ls", "git", "tools", "history") and how large the
+// rendered context is allowed to get before truncation.
+type ContextConfig struct {
+	Include  []string `mapstructure:"include"`
+	Exclude  []string `mapstructure:"exclude"`
+	MaxBytes int      `mapstructure:"max_bytes"`
+}
+
+// ModelConfig describes a named model alias: which provider/model backs
+// it, generation parameters, and an optional custom prompt template. Files
+// live under ~/.x/models/<alias>.yaml.
+type ModelConfig struct {
+	Provider       string   `yaml:"provider"`
+	Model          string   `yaml:"model"`
+	Temperature    float64  `yaml:"temperature"`
+	MaxTokens      int      `yaml:"max_tokens"`
+	StopSequences  []string `yaml:"stop_sequences"`
+	PromptTemplate string   `yaml:"prompt_template"`
+	Endpoint       string   `yaml:"endpoint"`
 }
 
 // Default model values
 const (
-	DefaultOpenAIModel    = "gpt-4o-mini"
-	DefaultAnthropicModel = "claude-3-5-haiku-20241022"
-	DefaultGeminiModel    = "gemini-2.0-flash-exp"
-	DefaultOllamaHost     = "http://localhost:11434"
-	DefaultTimeout        = 30
+	DefaultOpenAIModel     = "gpt-4o-mini"
+	DefaultAnthropicModel  = "claude-3-5-haiku-20241022"
+	DefaultGeminiModel     = "gemini-2.0-flash-exp"
+	DefaultOllamaHost      = "http://localhost:11434"
+	DefaultTimeout         = 30
+	DefaultContextMaxBytes = 4096
 )
 
 // Manager handles configuration loading and saving
@@ -76,6 +116,7 @@ func NewManager() (*Manager, error) {
 	v.BindEnv("gemini_api_key", "GEMINI_API_KEY")
 	v.BindEnv("ollama_model", "OLLAMA_MODEL")
 	v.BindEnv("ollama_host", "OLLAMA_HOST")
+	v.BindEnv("grpc_provider_addr", "GRPC_PROVIDER_ADDR")
 
 	// Set defaults
 	v.SetDefault("ollama_host", DefaultOllamaHost)
@@ -83,6 +124,7 @@ func NewManager() (*Manager, error) {
 	v.SetDefault("openai_model", DefaultOpenAIModel)
 	v.SetDefault("anthropic_model", DefaultAnthropicModel)
 	v.SetDefault("gemini_model", DefaultGeminiModel)
+	v.SetDefault("context.max_bytes", DefaultContextMaxBytes)
 
 	// Read config file (ignore if not found)
 	_ = v.ReadInConfig()
@@ -96,9 +138,54 @@ func (m *Manager) Load() (*Config, error) {
 	if err := m.v.Unmarshal(cfg); err != nil {
 		return nil, err
 	}
+
+	models, err := loadModelConfigs(filepath.Join(m.cfgDir, "models"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Models = models
+
 	return cfg, nil
 }
 
+// loadModelConfigs reads every *.yaml/*.yml file in dir into a map keyed
+// by filename without extension. A missing directory is not an error.
+func loadModelConfigs(dir string) (map[string]ModelConfig, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	models := make(map[string]ModelConfig)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(entry.Name())
+		if ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var mc ModelConfig
+		if err := yaml.Unmarshal(data, &mc); err != nil {
+			return nil, fmt.Errorf("parsing model config %s: %w", entry.Name(), err)
+		}
+
+		alias := strings.TrimSuffix(entry.Name(), ext)
+		models[alias] = mc
+	}
+
+	return models, nil
+}
+
 // SaveWorkingModel saves the working model for a provider
 func (m *Manager) SaveWorkingModel(provider, model string) error {
 	key := provider + "_model"
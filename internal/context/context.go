@@ -2,41 +2,85 @@ package context
 
 import (
 	"bufio"
+	stdcontext "context"
 	"fmt"
 	"os"
+	osexec "os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
+
+	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/context/history"
 )
 
 const (
-	maxFileLines      = 50   // Maximum lines to read from a file
-	maxHistoryLines   = 5    // Last N commands from history
-	maxFileSize       = 8192 // Max file size to read (8KB)
-	maxFilesPerPrompt = 3    // Max files to include in context
+	maxFileLines       = 50   // Maximum lines to read from a file
+	maxHistoryLines    = 5    // Last N commands from history
+	maxFileSize        = 8192 // Max file size to read (8KB)
+	maxFilesPerPrompt  = 3    // Max files to include in context
+	maxSimilarCommands = 5    // Max semantically-similar past commands to include
+
+	// defaultMaxBytes bounds Format's output when the caller's
+	// context.max_bytes config key is unset (zero).
+	defaultMaxBytes = 4096
 )
 
+// commonTools is the fixed, curated set of binaries whose presence is
+// reported to the model - not a scan of everything on PATH.
+var commonTools = []string{"docker", "kubectl", "terraform", "jq"}
+
+// sectionNames are the toggleable pieces of environment context beyond
+// the file-reference and semantic-recall context, which are always
+// gathered. "history" defaults to excluded - shell history can contain
+// secrets the user wouldn't want sent to a model provider - so it must
+// be named explicitly in context.include to turn on.
+var sectionNames = []string{"shell", "os", "cwd", "ls", "git", "tools", "history"}
+var defaultSections = []string{"shell", "os", "cwd", "ls", "git", "tools"}
+
 // Context holds all gathered context information
 type Context struct {
-	CurrentDir    string
-	OS            string
-	Shell         string
-	ShellHistory  []string
-	ReferencedFiles map[string]string // filename -> content
+	CurrentDir       string
+	OS               string
+	OSDistro         string // Linux PRETTY_NAME from /etc/os-release, empty elsewhere
+	Shell            string
+	ShellVersion     string
+	ShellHistory     []string
+	ReferencedFiles  map[string]string // filename -> content
 	DirectoryListing []string
+	SimilarCommands  []history.Record // past commands semantically similar to the instruction
+
+	GitBranch string
+	GitDirty  int
+	GitClean  bool
+	GitRemote string
+	Tools     []string // commonTools found on PATH
+
+	sections map[string]bool // which of sectionNames Format should render
 }
 
-// GetContext gathers all relevant context for the AI prompt
-func GetContext(instruction string) *Context {
+// GetContext gathers all relevant context for the AI prompt. cfg may be
+// nil, in which case semantic history recall is skipped and every
+// section defaults on except "history" (the trailing ShellHistory is
+// skipped too, since it's opt-in).
+func GetContext(instruction string, cfg *config.Config) *Context {
+	sections := enabledSections(cfg)
 	ctx := &Context{
 		ReferencedFiles: make(map[string]string),
+		sections:        sections,
 	}
 
 	// 1. System Info
 	ctx.CurrentDir = getCurrentDir()
 	ctx.OS = runtime.GOOS
+	if sections["os"] {
+		ctx.OSDistro = linuxDistro()
+	}
 	ctx.Shell = getShell()
+	if sections["shell"] {
+		ctx.ShellVersion = shellVersion(os.Getenv("SHELL"), ctx.Shell)
+	}
 
 	// 2. Directory listing (for awareness of available files)
 	ctx.DirectoryListing = getDirectoryListing()
@@ -44,24 +88,233 @@ func GetContext(instruction string) *Context {
 	// 3. File Awareness - detect and read referenced files
 	ctx.ReferencedFiles = detectAndReadFiles(instruction, ctx.DirectoryListing)
 
-	// 4. Shell History
-	ctx.ShellHistory = getShellHistory(ctx.Shell)
+	// 4. Shell History - opt-in only, see sectionNames above
+	if sections["history"] {
+		ctx.ShellHistory = getShellHistory(ctx.Shell)
+	}
+
+	// 5. Git state of the repo containing cwd, if any
+	if sections["git"] {
+		ctx.GitBranch, ctx.GitDirty, ctx.GitClean, ctx.GitRemote = gitInfo()
+	}
+
+	// 6. Presence of common tool binaries on PATH
+	if sections["tools"] {
+		ctx.Tools = toolsAvailable()
+	}
+
+	// 7. Semantic recall over the full shell history, in addition to the
+	// trailing few above. This is a best-effort enhancement - a missing
+	// index or unconfigured embedder just means no similar commands are
+	// offered, not a failed prompt.
+	ctx.SimilarCommands = similarCommands(instruction, ctx.CurrentDir, cfg)
 
 	return ctx
 }
 
-// Format returns the context as a formatted string for the AI prompt
-func (c *Context) Format() string {
+// enabledSections resolves cfg's context.include/context.exclude config
+// keys into the set of sections GetContext should gather and Format
+// should render, defaulting to defaultSections when cfg is nil or
+// context.include is empty.
+func enabledSections(cfg *config.Config) map[string]bool {
+	include := defaultSections
+	var exclude []string
+	if cfg != nil {
+		if len(cfg.Context.Include) > 0 {
+			include = cfg.Context.Include
+		}
+		exclude = cfg.Context.Exclude
+	}
+
+	excluded := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		excluded[name] = true
+	}
+
+	enabled := make(map[string]bool, len(sectionNames))
+	for _, name := range include {
+		if !excluded[name] && knownSection(name) {
+			enabled[name] = true
+		}
+	}
+	return enabled
+}
+
+func knownSection(name string) bool {
+	for _, s := range sectionNames {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// linuxDistro reads PRETTY_NAME out of /etc/os-release, the standard way
+// to identify a Linux distribution; empty on any other OS or if the
+// file is missing.
+func linuxDistro() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	data, err := os.ReadFile("/etc/os-release")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if name, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+			return strings.Trim(name, `"`)
+		}
+	}
+	return ""
+}
+
+// shellVersion runs the shell's own --version flag, which bash, zsh, and
+// fish all support; other shells (dash, sh) don't reliably report a
+// version this way, so they're skipped rather than guessed at.
+func shellVersion(shellPath, shellName string) string {
+	switch shellName {
+	case "bash", "zsh", "fish":
+	default:
+		return ""
+	}
+	if shellPath == "" {
+		return ""
+	}
+
+	out, err := osexec.Command(shellPath, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+}
+
+// gitInfo reports the current branch, dirty-file count, and remote URL
+// of the repo containing cwd by shelling out to the same `git` binary a
+// generated command would run against, rather than parsing .git
+// internals directly. It returns zero values outside a git repo.
+func gitInfo() (branch string, dirty int, clean bool, remote string) {
+	if _, err := osexec.LookPath("git"); err != nil {
+		return "", 0, false, ""
+	}
+
+	branch, err := runGit("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", 0, false, ""
+	}
+
+	if status, err := runGit("status", "--porcelain"); err == nil {
+		for _, line := range strings.Split(status, "\n") {
+			if strings.TrimSpace(line) != "" {
+				dirty++
+			}
+		}
+	}
+	clean = dirty == 0
+
+	remote, _ = runGit("remote", "get-url", "origin")
+	return branch, dirty, clean, remote
+}
+
+func runGit(args ...string) (string, error) {
+	out, err := osexec.Command("git", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// toolsAvailable reports which of commonTools are on PATH, so the model
+// knows whether it can reach for `jq` or has to fall back to
+// `sed`/`awk`.
+func toolsAvailable() []string {
+	var present []string
+	for _, tool := range commonTools {
+		if _, err := osexec.LookPath(tool); err == nil {
+			present = append(present, tool)
+		}
+	}
+	return present
+}
+
+// similarCommands retrieves the top-k indexed commands most similar to
+// instruction from the on-disk semantic history index. The index itself
+// is populated out-of-band by history.Ingest, not here.
+func similarCommands(instruction, cwd string, cfg *config.Config) []history.Record {
+	if cfg == nil {
+		return nil
+	}
+
+	store, err := history.Open()
+	if err != nil {
+		return nil
+	}
+
+	embedder, err := history.NewEmbedder(cfg)
+	if err != nil {
+		return nil
+	}
+
+	embedding, err := embedder.Embed(stdcontext.Background(), instruction)
+	if err != nil {
+		return nil
+	}
+
+	return store.TopK(embedding, cwd, maxSimilarCommands)
+}
+
+// Format returns the context as a formatted string for the AI prompt,
+// truncated to at most maxBytes bytes (defaultMaxBytes if maxBytes <= 0).
+// Sections not enabled via GetContext's cfg are omitted entirely rather
+// than rendered empty.
+func (c *Context) Format(maxBytes int) string {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+
 	var b strings.Builder
 
 	// System info
 	b.WriteString("=== SYSTEM CONTEXT ===\n")
-	b.WriteString(fmt.Sprintf("Current Directory: %s\n", c.CurrentDir))
-	b.WriteString(fmt.Sprintf("Operating System: %s\n", c.OS))
-	b.WriteString(fmt.Sprintf("Shell: %s\n", c.Shell))
+	if c.sections["cwd"] {
+		b.WriteString(fmt.Sprintf("Current Directory: %s\n", c.CurrentDir))
+	}
+	if c.sections["os"] {
+		os := c.OS
+		if c.OSDistro != "" {
+			os = fmt.Sprintf("%s (%s)", os, c.OSDistro)
+		}
+		b.WriteString(fmt.Sprintf("Operating System: %s\n", os))
+	}
+	if c.sections["shell"] {
+		shell := c.Shell
+		if c.ShellVersion != "" {
+			shell = fmt.Sprintf("%s (%s)", shell, c.ShellVersion)
+		}
+		b.WriteString(fmt.Sprintf("Shell: %s\n", shell))
+	}
+
+	// Git state of the repo containing cwd
+	if c.sections["git"] && c.GitBranch != "" {
+		status := "clean"
+		if !c.GitClean {
+			status = fmt.Sprintf("%d file(s) modified", c.GitDirty)
+		}
+		b.WriteString("\n=== GIT ===\n")
+		b.WriteString(fmt.Sprintf("Branch: %s (%s)\n", c.GitBranch, status))
+		if c.GitRemote != "" {
+			b.WriteString(fmt.Sprintf("Remote: %s\n", c.GitRemote))
+		}
+	}
+
+	// Common tool binaries available on PATH
+	if c.sections["tools"] && len(c.Tools) > 0 {
+		b.WriteString("\n=== AVAILABLE TOOLS ===\n")
+		b.WriteString(strings.Join(c.Tools, ", "))
+		b.WriteString("\n")
+	}
 
 	// Directory listing (abbreviated)
-	if len(c.DirectoryListing) > 0 {
+	if c.sections["ls"] && len(c.DirectoryListing) > 0 {
 		b.WriteString("\n=== FILES IN CURRENT DIRECTORY ===\n")
 		// Show up to 20 files
 		count := len(c.DirectoryListing)
@@ -84,6 +337,22 @@ func (c *Context) Format() string {
 		}
 	}
 
+	// Semantically similar past commands
+	if len(c.SimilarCommands) > 0 {
+		b.WriteString("\n=== SIMILAR PAST COMMANDS ===\n")
+		for _, r := range c.SimilarCommands {
+			status := "unknown"
+			if r.HasExitCode {
+				status = fmt.Sprintf("exit %d", r.ExitCode)
+			}
+			cwd := r.Cwd
+			if cwd == "" {
+				cwd = "unknown dir"
+			}
+			b.WriteString(fmt.Sprintf("  [%s, %s, %s] %s\n", r.Timestamp.Format("2006-01-02 15:04"), cwd, status, r.Command))
+		}
+	}
+
 	// Referenced file contents
 	if len(c.ReferencedFiles) > 0 {
 		for filename, content := range c.ReferencedFiles {
@@ -93,7 +362,11 @@ func (c *Context) Format() string {
 		}
 	}
 
-	return b.String()
+	out := b.String()
+	if len(out) > maxBytes {
+		out = out[:maxBytes]
+	}
+	return out
 }
 
 // HasFileContext returns true if any files were detected and read
@@ -197,12 +470,13 @@ func detectAndReadFiles(instruction string, dirListing []string) map[string]stri
 	}
 
 	// Read the found files (up to maxFilesPerPrompt)
+	focus := focusWordsFrom(instruction)
 	count := 0
 	for filename := range foundFiles {
 		if count >= maxFilesPerPrompt {
 			break
 		}
-		content, err := readFileContent(filename)
+		content, err := readFileOutline(filename, focus)
 		if err == nil && content != "" {
 			files[filename] = content
 			count++
@@ -212,8 +486,11 @@ func detectAndReadFiles(instruction string, dirListing []string) map[string]stri
 	return files
 }
 
-// readFileContent reads a file and returns its content (truncated if too large)
-func readFileContent(filename string) (string, error) {
+// readFileOutline reads a file and distills it into an outline via the
+// FileSummarizer registered for its extension, rather than a blind
+// first-N-lines excerpt, so long files still fit the prompt without losing
+// the declarations the instruction is actually about.
+func readFileOutline(filename string, focus map[string]bool) (string, error) {
 	// Check file size first
 	info, err := os.Stat(filename)
 	if err != nil {
@@ -230,34 +507,23 @@ func readFileContent(filename string) (string, error) {
 		return fmt.Sprintf("[File too large: %d bytes, showing first %d bytes]\n", info.Size(), maxFileSize), nil
 	}
 
-	// Open and read the file
-	file, err := os.Open(filename)
+	content, err := os.ReadFile(filename)
 	if err != nil {
 		return "", err
 	}
-	defer file.Close()
 
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	lineCount := 0
-
-	for scanner.Scan() && lineCount < maxFileLines {
-		lines = append(lines, scanner.Text())
-		lineCount++
-	}
-
-	content := strings.Join(lines, "\n")
-
-	// Add truncation notice if we didn't read the whole file
-	if lineCount >= maxFileLines {
-		content += fmt.Sprintf("\n... [truncated at %d lines]", maxFileLines)
-	}
-
-	return content, nil
+	return summarizerFor(filename).Summarize(filename, string(content), focus)
 }
 
-// getShellHistory reads recent commands from shell history
+// getShellHistory reads recent commands from shell history, preferring
+// $HISTFILE (the shell's own idea of where its history lives, and the
+// only way to find it when it's been relocated) before falling back to
+// the conventional per-shell path.
 func getShellHistory(shell string) []string {
+	if histfile := os.Getenv("HISTFILE"); histfile != "" {
+		return readLastLines(histfile, maxHistoryLines, shell)
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil
@@ -0,0 +1,174 @@
+// Package history is a semantic recall subsystem for shell history: it
+// embeds past commands with a pluggable Embedder, persists them to a
+// local flat-file index (Store), and retrieves the top-k commands most
+// similar to the user's current instruction so internal/context can offer
+// the model more than just the trailing few raw commands.
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/provider/transport"
+)
+
+// Embedder turns text into a fixed-size vector for similarity search.
+// Implementations wrap whichever provider's embeddings endpoint is
+// configured; Ingest and Store.TopK are agnostic to which one is in use.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// NewEmbedder picks an Embedder from cfg the same way provider.Registry
+// picks a command provider: the first of OpenAI, Gemini, or Ollama
+// (nomic-embed-text) that's configured.
+func NewEmbedder(cfg *config.Config) (Embedder, error) {
+	switch {
+	case cfg.OpenAIAPIKey != "":
+		return &openAIEmbedder{apiKey: cfg.OpenAIAPIKey, transport: transport.Default}, nil
+	case cfg.GeminiAPIKey != "":
+		return &geminiEmbedder{apiKey: cfg.GeminiAPIKey, transport: transport.Default}, nil
+	case cfg.OllamaModel != "":
+		host := cfg.OllamaHost
+		if host == "" {
+			host = config.DefaultOllamaHost
+		}
+		return &ollamaEmbedder{host: host, transport: transport.Default}, nil
+	default:
+		return nil, fmt.Errorf("no embedding provider configured")
+	}
+}
+
+type openAIEmbedder struct {
+	apiKey    string
+	transport transport.Transport
+}
+
+func (e *openAIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	reqBody, err := json.Marshal(map[string]string{
+		"model": "text-embedding-3-small",
+		"input": text,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/embeddings", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+
+	resp, err := e.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("embedding request failed: %s", result.Error.Message)
+	}
+	if len(result.Data) == 0 {
+		return nil, fmt.Errorf("embedding request returned no data")
+	}
+	return result.Data[0].Embedding, nil
+}
+
+type geminiEmbedder struct {
+	apiKey    string
+	transport transport.Transport
+}
+
+func (e *geminiEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"model":   "models/text-embedding-004",
+		"content": map[string]interface{}{"parts": []map[string]string{{"text": text}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/text-embedding-004:embedContent?key=%s", e.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Embedding struct {
+			Values []float32 `json:"values"`
+		} `json:"embedding"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("embedding request failed: %s", result.Error.Message)
+	}
+	return result.Embedding.Values, nil
+}
+
+type ollamaEmbedder struct {
+	host      string
+	transport transport.Transport
+}
+
+func (e *ollamaEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	reqBody, err := json.Marshal(map[string]string{
+		"model":  "nomic-embed-text",
+		"prompt": text,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.host+"/api/embeddings", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.transport.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama at %s: %w", e.host, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Embedding []float32 `json:"embedding"`
+		Error     string    `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("embedding request failed: %s", result.Error)
+	}
+	return result.Embedding, nil
+}
@@ -0,0 +1,138 @@
+package history
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/REDFOX1899/ask-sh/internal/session"
+)
+
+// zshExtendedHistoryPattern matches zsh's `: <timestamp>:<elapsed>;<command>`
+// extended-history format, the same one readLastLines in internal/context
+// already tolerates for its plain trailing-N-lines recall.
+var zshExtendedHistoryPattern = regexp.MustCompile(`^: (\d+):\d+;(.*)$`)
+
+// Ingest reads the full shell history plus this tool's own session
+// history, embeds any command not already in the index, and persists the
+// result. It's meant to run periodically (e.g. once per REPL start) rather
+// than on every prompt, since embedding calls are real API requests.
+func Ingest(ctx context.Context, store *Store, embedder Embedder, shell string) error {
+	for _, rec := range shellHistoryRecords(shell) {
+		if store.Has(rec.Command) {
+			continue
+		}
+		embedding, err := embedder.Embed(ctx, rec.Command)
+		if err != nil {
+			return err
+		}
+		rec.Embedding = embedding
+		if err := store.Append(rec); err != nil {
+			return err
+		}
+	}
+
+	sessionRecords, err := sessionHistoryRecords()
+	if err != nil {
+		return err
+	}
+	for _, rec := range sessionRecords {
+		if store.Has(rec.Command) {
+			continue
+		}
+		embedding, err := embedder.Embed(ctx, rec.Command)
+		if err != nil {
+			return err
+		}
+		rec.Embedding = embedding
+		if err := store.Append(rec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shellHistoryRecords parses the full zsh/bash history file. Plain shell
+// history carries no cwd or exit status, only a timestamp when the shell
+// is configured for extended history (HIST_EXTENDED); both are left at
+// their zero value otherwise.
+func shellHistoryRecords(shell string) []Record {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	var historyFile string
+	switch shell {
+	case "zsh":
+		historyFile = filepath.Join(homeDir, ".zsh_history")
+	case "fish":
+		historyFile = filepath.Join(homeDir, ".local/share/fish/fish_history")
+	default:
+		historyFile = filepath.Join(homeDir, ".bash_history")
+	}
+
+	f, err := os.Open(historyFile)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		rec := Record{Command: line}
+		if m := zshExtendedHistoryPattern.FindStringSubmatch(line); m != nil {
+			if ts, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+				rec.Timestamp = time.Unix(ts, 0)
+			}
+			rec.Command = strings.TrimSpace(m[2])
+		}
+		if rec.Command == "" {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// sessionHistoryRecords reads this tool's own ~/.x/history.jsonl, which
+// (unlike raw shell history) already carries an exit code for "executed"
+// and "failed" turns.
+func sessionHistoryRecords() ([]Record, error) {
+	hist, err := session.Open()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := hist.All()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(entries))
+	for _, e := range entries {
+		if e.Command == "" {
+			continue
+		}
+		records = append(records, Record{
+			Timestamp:   e.Timestamp,
+			Command:     e.Command,
+			ExitCode:    e.ExitCode,
+			HasExitCode: e.Outcome == "executed" || e.Outcome == "failed",
+		})
+	}
+	return records, nil
+}
@@ -0,0 +1,161 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Record is one indexed shell command: its embedding plus enough metadata
+// (when, where, whether it succeeded) for a caller to prefer commands that
+// worked in the current directory over ones that didn't.
+type Record struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Cwd         string    `json:"cwd,omitempty"`
+	Command     string    `json:"command"`
+	ExitCode    int       `json:"exit_code"`
+	HasExitCode bool      `json:"has_exit_code,omitempty"`
+	Embedding   []float32 `json:"embedding"`
+}
+
+// DefaultPath returns ~/.x/history.idx, the flat-file ANN index written by
+// Ingest and searched by Store.TopK.
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".x", "history.idx"), nil
+}
+
+// Store is a flat-file vector index: every Record lives in memory and on
+// disk as one JSON object per line, and a query is a linear scan for
+// cosine similarity. That's the right trade-off at shell-history scale
+// (thousands, not millions, of commands) and keeps the index readable
+// with standard line tools.
+type Store struct {
+	path    string
+	records []Record
+}
+
+// Open loads the index at its default location (~/.x/history.idx),
+// creating ~/.x if needed. An index that doesn't exist yet is not an
+// error - it just starts empty.
+func Open() (*Store, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{path: path}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			continue
+		}
+		s.records = append(s.records, r)
+	}
+	return s, scanner.Err()
+}
+
+// Has reports whether command is already indexed, so Ingest can skip
+// re-embedding commands it's seen before.
+func (s *Store) Has(command string) bool {
+	for _, r := range s.records {
+		if r.Command == command {
+			return true
+		}
+	}
+	return false
+}
+
+// Append adds r to the index and persists it immediately.
+func (s *Store) Append(r Record) error {
+	s.records = append(s.records, r)
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// TopK returns the k indexed records most similar to query by cosine
+// similarity, most-similar first. Records sharing cwd with the caller's
+// current directory are given a small boost, and records known to have
+// failed (HasExitCode && ExitCode != 0) a small penalty, so the model
+// sees commands that worked here ahead of ones that didn't.
+func (s *Store) TopK(query []float32, cwd string, k int) []Record {
+	type scored struct {
+		record Record
+		score  float64
+	}
+	candidates := make([]scored, 0, len(s.records))
+	for _, r := range s.records {
+		sim := cosineSimilarity(query, r.Embedding)
+		if r.Cwd != "" && r.Cwd == cwd {
+			sim += 0.05
+		}
+		if r.HasExitCode && r.ExitCode != 0 {
+			sim -= 0.1
+		}
+		candidates = append(candidates, scored{r, sim})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	out := make([]Record, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.record
+	}
+	return out
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return -1
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return -1
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,93 @@
+package context
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FileSummarizer distills a file's content into a token-budget-friendly
+// outline instead of a blind line-count excerpt: top-level declarations,
+// exported symbols, imports, and the full bodies of only the declarations
+// whose names appear in focus. Providers can register their own
+// implementation via SetSummarizer to go beyond the built-in ones.
+type FileSummarizer interface {
+	// Summarize returns a distilled outline of content. focus holds the
+	// identifiers mentioned in the user's instruction (e.g. "handleFoo"
+	// from "refactor handleFoo in server.go"); a summarizer should expand
+	// the full body of any declaration whose name is in focus rather than
+	// just its signature.
+	Summarize(filename, content string, focus map[string]bool) (string, error)
+}
+
+// summarizers maps a file extension (including the leading dot, lowercase)
+// to the FileSummarizer used for it. Extensions with no entry fall back to
+// excerptSummarizer.
+var summarizers = map[string]FileSummarizer{
+	".go":   goSummarizer{},
+	".yaml": yamlSummarizer{},
+	".yml":  yamlSummarizer{},
+}
+
+func init() {
+	ts := treeSitterSummarizer{}
+	for _, ext := range []string{".py", ".js", ".jsx", ".ts", ".tsx", ".rs"} {
+		summarizers[ext] = ts
+	}
+}
+
+// SetSummarizer registers summarizer as the FileSummarizer for ext
+// (including the leading dot, e.g. ".go"), overriding the built-in one.
+func SetSummarizer(ext string, summarizer FileSummarizer) {
+	summarizers[strings.ToLower(ext)] = summarizer
+}
+
+// summarizerFor returns the FileSummarizer registered for filename's
+// extension, or excerptSummarizer if none is registered.
+func summarizerFor(filename string) FileSummarizer {
+	ext := strings.ToLower(fileExt(filename))
+	if s, ok := summarizers[ext]; ok {
+		return s
+	}
+	return excerptSummarizer{}
+}
+
+func fileExt(filename string) string {
+	if i := strings.LastIndexByte(filename, '.'); i >= 0 {
+		return filename[i:]
+	}
+	return ""
+}
+
+// focusWordsFrom extracts the bare identifiers mentioned in instruction so
+// summarizers know which declarations to expand in full. It's deliberately
+// loose (plain whitespace/punctuation splitting) since false positives just
+// mean a declaration is shown in full rather than as a signature.
+func focusWordsFrom(instruction string) map[string]bool {
+	focus := make(map[string]bool)
+	for _, word := range strings.Fields(instruction) {
+		word = strings.Trim(word, ",.;:!?\"'()[]{}`")
+		if word == "" {
+			continue
+		}
+		focus[word] = true
+	}
+	return focus
+}
+
+// excerptSummarizer is the fallback for extensions with no dedicated
+// FileSummarizer: it's the original behavior of this package, a blind
+// first-maxFileLines-lines excerpt.
+type excerptSummarizer struct{}
+
+func (excerptSummarizer) Summarize(filename, content string, focus map[string]bool) (string, error) {
+	lines := strings.Split(content, "\n")
+	truncated := len(lines) > maxFileLines
+	if truncated {
+		lines = lines[:maxFileLines]
+	}
+	out := strings.Join(lines, "\n")
+	if truncated {
+		out += "\n... [truncated at " + strconv.Itoa(maxFileLines) + " lines]"
+	}
+	return out, nil
+}
@@ -0,0 +1,117 @@
+package context
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// goSummarizer outlines a Go source file using the standard library's own
+// parser: package/imports, then one line per top-level declaration
+// (signature only), except declarations named in focus, whose full source
+// is included instead.
+type goSummarizer struct{}
+
+func (goSummarizer) Summarize(filename, content string, focus map[string]bool) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, content, parser.ParseComments)
+	if err != nil {
+		// Truncated or invalid source (e.g. cut off by maxFileSize) - fall
+		// back to a plain excerpt rather than failing the whole prompt.
+		return excerptSummarizer{}.Summarize(filename, content, focus)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n", file.Name.Name)
+
+	if len(file.Imports) > 0 {
+		b.WriteString("\nimports:\n")
+		for _, imp := range file.Imports {
+			fmt.Fprintf(&b, "  %s\n", imp.Path.Value)
+		}
+	}
+
+	b.WriteString("\ndeclarations:\n")
+	for _, decl := range file.Decls {
+		name, expand := declSummary(decl, focus)
+		if name == "" {
+			continue
+		}
+		if expand {
+			b.WriteString("\n")
+			if err := printNode(&b, fset, decl); err != nil {
+				fmt.Fprintf(&b, "  %s (source unavailable: %v)\n", name, err)
+			}
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(&b, "  %s\n", name)
+	}
+
+	return b.String(), nil
+}
+
+// declSummary returns the one-line signature of decl and whether its full
+// body should be expanded because its name was mentioned in focus.
+func declSummary(decl ast.Decl, focus map[string]bool) (signature string, expand bool) {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		sig := funcSignature(d)
+		return sig, focus[d.Name.Name]
+	case *ast.GenDecl:
+		var names []string
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					names = append(names, n.Name)
+				}
+			}
+		}
+		if len(names) == 0 {
+			return "", false
+		}
+		for _, n := range names {
+			if focus[n] {
+				return strings.Join(names, ", "), true
+			}
+		}
+		return fmt.Sprintf("%s %s", d.Tok, strings.Join(names, ", ")), false
+	default:
+		return "", false
+	}
+}
+
+func funcSignature(d *ast.FuncDecl) string {
+	recv := ""
+	if d.Recv != nil && len(d.Recv.List) == 1 {
+		recv = fmt.Sprintf("(%s) ", exprString(d.Recv.List[0].Type))
+	}
+	return fmt.Sprintf("func %s%s(...)", recv, d.Name.Name)
+}
+
+func exprString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + exprString(e.X)
+	case *ast.Ident:
+		return e.Name
+	default:
+		return "?"
+	}
+}
+
+func printNode(b *strings.Builder, fset *token.FileSet, node ast.Node) error {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return err
+	}
+	b.Write(buf.Bytes())
+	return nil
+}
@@ -0,0 +1,81 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/rust"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+)
+
+// treeSitterSummarizer outlines Python, JavaScript, TypeScript, and Rust
+// source with tree-sitter: a shebang line if present, then one line per
+// top-level declaration, except declarations named in focus, whose full
+// source text is included instead.
+type treeSitterSummarizer struct{}
+
+func (treeSitterSummarizer) Summarize(filename, content string, focus map[string]bool) (string, error) {
+	lang := treeSitterLanguage(filename)
+	if lang == nil {
+		return excerptSummarizer{}.Summarize(filename, content, focus)
+	}
+
+	src := []byte(content)
+	tree, err := sitter.ParseCtx(context.Background(), src, lang)
+	if err != nil {
+		return excerptSummarizer{}.Summarize(filename, content, focus)
+	}
+
+	var b strings.Builder
+	if strings.HasPrefix(content, "#!") {
+		fmt.Fprintf(&b, "%s\n\n", strings.SplitN(content, "\n", 2)[0])
+	}
+
+	b.WriteString("declarations:\n")
+	root := tree.RootNode()
+	for i := 0; i < int(root.ChildCount()); i++ {
+		node := root.Child(i)
+		name, expand := topLevelDeclName(node, src, focus)
+		if name == "" {
+			continue
+		}
+		if expand {
+			fmt.Fprintf(&b, "\n%s\n\n", node.Content(src))
+			continue
+		}
+		fmt.Fprintf(&b, "  %s: %s\n", node.Type(), name)
+	}
+
+	return b.String(), nil
+}
+
+func treeSitterLanguage(filename string) *sitter.Language {
+	switch fileExt(filename) {
+	case ".py":
+		return python.GetLanguage()
+	case ".js", ".jsx":
+		return javascript.GetLanguage()
+	case ".ts", ".tsx":
+		return typescript.GetLanguage()
+	case ".rs":
+		return rust.GetLanguage()
+	default:
+		return nil
+	}
+}
+
+// topLevelDeclName returns the identifier a top-level node declares (a
+// function, class, struct, or similar) and whether it was mentioned in
+// focus, or "" if the node isn't a named declaration worth listing.
+func topLevelDeclName(node *sitter.Node, src []byte, focus map[string]bool) (name string, expand bool) {
+	nameNode := node.ChildByFieldName("name")
+	if nameNode == nil {
+		return "", false
+	}
+	name = nameNode.Content(src)
+	return name, focus[name]
+}
@@ -0,0 +1,66 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlSummarizer outlines a YAML document as its top-level keys, with one
+// level of nesting shown for mapping values, except keys named in focus,
+// whose full value is included instead.
+type yamlSummarizer struct{}
+
+func (yamlSummarizer) Summarize(filename, content string, focus map[string]bool) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return excerptSummarizer{}.Summarize(filename, content, focus)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return excerptSummarizer{}.Summarize(filename, content, focus)
+	}
+
+	root := doc.Content[0]
+	var b strings.Builder
+	b.WriteString("keys:\n")
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		val := root.Content[i+1]
+
+		if focus[key.Value] {
+			out, err := yaml.Marshal(val)
+			if err == nil {
+				fmt.Fprintf(&b, "\n%s:\n%s\n", key.Value, indent(string(out)))
+				continue
+			}
+		}
+
+		switch val.Kind {
+		case yaml.MappingNode:
+			fmt.Fprintf(&b, "  %s: {%s}\n", key.Value, strings.Join(mappingKeys(val), ", "))
+		case yaml.SequenceNode:
+			fmt.Fprintf(&b, "  %s: [%d items]\n", key.Value, len(val.Content))
+		default:
+			fmt.Fprintf(&b, "  %s: %s\n", key.Value, val.Value)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func mappingKeys(node *yaml.Node) []string {
+	keys := make([]string, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+	return keys
+}
+
+func indent(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = "  " + l
+	}
+	return strings.Join(lines, "\n")
+}
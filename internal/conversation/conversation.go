@@ -0,0 +1,133 @@
+// Package conversation stores multi-turn refinement sessions as a tree of
+// messages, so editing a prior message branches instead of overwriting.
+package conversation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Message is one node in a conversation tree.
+type Message struct {
+	ID        string    `json:"id"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	Role      string    `json:"role"` // "user" or "assistant"
+	Content   string    `json:"content"`
+	Provider  string    `json:"provider,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Conversation is a session's full message tree, stored as a flat slice
+// with ParentID links so branches don't require copying history.
+type Conversation struct {
+	ID       string    `json:"id"`
+	Messages []Message `json:"messages"`
+}
+
+// AddMessage appends msg as a new node in the tree and assigns it an ID.
+// Editing a prior message means calling AddMessage with the same ParentID
+// as the message being edited, which creates a new branch rather than
+// overwriting the original.
+func (c *Conversation) AddMessage(msg Message) Message {
+	msg.ID = fmt.Sprintf("%s-%d", c.ID, len(c.Messages))
+	msg.Timestamp = time.Now()
+	c.Messages = append(c.Messages, msg)
+	return msg
+}
+
+// Leaf returns the most recently added message, i.e. the tip of whichever
+// branch was last extended.
+func (c *Conversation) Leaf() (Message, bool) {
+	if len(c.Messages) == 0 {
+		return Message{}, false
+	}
+	return c.Messages[len(c.Messages)-1], true
+}
+
+// Find returns the message with the given ID.
+func (c *Conversation) Find(id string) (Message, bool) {
+	for _, m := range c.Messages {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return Message{}, false
+}
+
+// Branch returns the path from the root to id, following ParentID links.
+func (c *Conversation) Branch(id string) []Message {
+	byID := make(map[string]Message, len(c.Messages))
+	for _, m := range c.Messages {
+		byID[m.ID] = m
+	}
+
+	var path []Message
+	for cur, ok := byID[id]; ok; {
+		path = append([]Message{cur}, path...)
+		if cur.ParentID == "" {
+			break
+		}
+		cur, ok = byID[cur.ParentID]
+	}
+	return path
+}
+
+// Store persists conversations as one JSON file per ID under
+// ~/.x/conversations/.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a Store rooted at ~/.x/conversations, creating the
+// directory if needed.
+func NewStore() (*Store, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(homeDir, ".x", "conversations")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// New creates a fresh, unsaved Conversation with a unique ID.
+func (s *Store) New() *Conversation {
+	return &Conversation{ID: fmt.Sprintf("%d", time.Now().UnixNano())}
+}
+
+// Load reads a conversation by ID.
+func (s *Store) Load(id string) (*Conversation, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return nil, err
+	}
+	var c Conversation
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Save writes a conversation to disk.
+func (s *Store) Save(c *Conversation) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(c.ID), data, 0644)
+}
+
+// Remove deletes a conversation's file.
+func (s *Store) Remove(id string) error {
+	return os.Remove(s.path(id))
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
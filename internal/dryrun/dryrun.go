@@ -0,0 +1,397 @@
+// Package dryrun simulates the filesystem effect of a command before it
+// runs, so a user staring at a confirmation prompt can see exactly which
+// paths would be touched. It understands the same "structured" surface the
+// safety analyzer does - rm, mv, cp, chmod, chown, mkdir, dd, and output
+// redirections - by reusing internal/safety/parse to get real argv instead
+// of scraping raw text. Path arguments are glob-expanded with
+// filepath.Glob and have `~` and env vars resolved before being Lstat'd,
+// so the preview reflects what's actually on disk. Commands outside that
+// surface come back with Supported=false; Trace offers a best-effort
+// fallback for those.
+package dryrun
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/REDFOX1899/ask-sh/internal/safety/parse"
+)
+
+// Target is one filesystem path a command would touch, resolved and
+// Lstat'd so the preview can show whether it exists, its kind, and its
+// size.
+type Target struct {
+	Path   string
+	Exists bool
+	IsDir  bool
+	Size   int64 // 0 for directories; a recursive total is folded into Result.TotalSize instead
+}
+
+// Result is the outcome of previewing a command's filesystem effect.
+type Result struct {
+	// Action names what the command would do: "delete", "move", "copy",
+	// "change permissions of", "change ownership of", "create directory",
+	// or "write to". Empty when Supported is false.
+	Action string
+
+	// Root is the longest common directory of every target, used for the
+	// "under /home/me/work/**" part of Summary.
+	Root string
+
+	Targets    []Target // capped at maxTreeEntries; see Truncated
+	TotalFiles int
+	TotalSize  int64
+
+	// Truncated is set when Targets was capped below MatchCount, so the
+	// caller can render "...and N more".
+	Truncated  bool
+	MatchCount int
+
+	// Supported is false when the command isn't one dryrun understands
+	// structurally. Notes then carries whatever best-effort tracing (see
+	// Trace) turned up, or an explanation of why nothing could be shown.
+	Supported bool
+	Notes     []string
+}
+
+// maxTreeEntries caps how many resolved targets a Result keeps around for
+// display; previewing a `rm -rf` over a directory with a million files
+// shouldn't require rendering (or Lstat-ing more than) a screenful of them.
+const maxTreeEntries = 20
+
+// Preview parses command and, for every simple command it contains that
+// dryrun understands structurally, expands its path arguments and Lstats
+// every match to report what it would do. The results of multiple simple
+// commands (e.g. `rm -rf a && rm -rf b`) are merged into one Result.
+func Preview(command string) (*Result, error) {
+	commands, err := parse.Parse(command)
+	if err != nil {
+		return nil, fmt.Errorf("parsing command: %w", err)
+	}
+
+	merged := &Result{}
+	found := false
+	for _, c := range commands {
+		r := previewCommand(c)
+		if r == nil {
+			continue
+		}
+		found = true
+		merge(merged, r)
+	}
+	if !found {
+		return &Result{Notes: []string{"command has no structurally recognized filesystem effect"}}, nil
+	}
+	merged.Supported = true
+	merged.Root = commonDir(merged.Targets)
+	return merged, nil
+}
+
+func previewCommand(c parse.Command) *Result {
+	switch c.Name() {
+	case "rm":
+		return previewRemove(c)
+	case "mv":
+		return previewMoveOrCopy(c, "move")
+	case "cp":
+		return previewMoveOrCopy(c, "copy")
+	case "chmod":
+		return previewModeChange(c, "change permissions of")
+	case "chown":
+		return previewModeChange(c, "change ownership of")
+	case "mkdir":
+		return previewMkdir(c)
+	case "dd":
+		return previewDD(c)
+	default:
+		return previewRedirect(c)
+	}
+}
+
+func previewRemove(c parse.Command) *Result {
+	raws := pathArgs(c.Argv)
+	if len(raws) == 0 {
+		return nil
+	}
+	recursive := c.HasFlag("-r") || c.HasFlag("--recursive")
+
+	r := &Result{Action: "delete"}
+	for _, path := range resolvePaths(raws) {
+		r.MatchCount++
+		if len(r.Targets) >= maxTreeEntries {
+			r.Truncated = true
+			continue
+		}
+		t := lstatTarget(path)
+		r.Targets = append(r.Targets, t)
+		if !t.Exists {
+			continue
+		}
+		if t.IsDir && recursive {
+			files, size := walkDir(path)
+			r.TotalFiles += files
+			r.TotalSize += size
+			continue
+		}
+		if !t.IsDir {
+			r.TotalFiles++
+			r.TotalSize += t.Size
+		}
+	}
+	return r
+}
+
+// previewMoveOrCopy treats every path argument but the last as a source,
+// and the last as the destination. It doesn't try to distinguish "move N
+// files into an existing directory" from "rename to this exact path" -
+// both render as the same list of sources plus the one destination, which
+// is enough for a user to sanity-check before confirming.
+func previewMoveOrCopy(c parse.Command, action string) *Result {
+	raws := pathArgs(c.Argv)
+	if len(raws) < 2 {
+		return nil
+	}
+	sources, dest := raws[:len(raws)-1], raws[len(raws)-1]
+
+	r := &Result{Action: action}
+	for _, path := range resolvePaths(sources) {
+		r.MatchCount++
+		if len(r.Targets) >= maxTreeEntries {
+			r.Truncated = true
+			continue
+		}
+		t := lstatTarget(path)
+		r.Targets = append(r.Targets, t)
+		if t.Exists && !t.IsDir {
+			r.TotalFiles++
+			r.TotalSize += t.Size
+		}
+	}
+
+	destPath := expandPath(dest)
+	if t := lstatTarget(destPath); t.Exists {
+		r.Notes = append(r.Notes, fmt.Sprintf("destination %s already exists and would be overwritten", destPath))
+	}
+	return r
+}
+
+func previewModeChange(c parse.Command, action string) *Result {
+	raws := pathArgs(c.Argv)
+	if len(raws) < 2 {
+		return nil
+	}
+	// argv[1] (after flags) is the mode/owner spec, not a path.
+	raws = raws[1:]
+
+	r := &Result{Action: action}
+	for _, path := range resolvePaths(raws) {
+		r.MatchCount++
+		if len(r.Targets) >= maxTreeEntries {
+			r.Truncated = true
+			continue
+		}
+		r.Targets = append(r.Targets, lstatTarget(path))
+	}
+	return r
+}
+
+func previewMkdir(c parse.Command) *Result {
+	raws := pathArgs(c.Argv)
+	if len(raws) == 0 {
+		return nil
+	}
+	r := &Result{Action: "create directory"}
+	for _, raw := range raws {
+		path := expandPath(raw)
+		r.MatchCount++
+		if len(r.Targets) >= maxTreeEntries {
+			r.Truncated = true
+			continue
+		}
+		r.Targets = append(r.Targets, lstatTarget(path))
+	}
+	return r
+}
+
+func previewDD(c parse.Command) *Result {
+	var of string
+	for _, a := range c.Argv[1:] {
+		if v, ok := strings.CutPrefix(a, "of="); ok {
+			of = v
+		}
+	}
+	if of == "" {
+		return nil
+	}
+	path := expandPath(of)
+	return &Result{
+		Action:     "write to",
+		Targets:    []Target{lstatTarget(path)},
+		MatchCount: 1,
+	}
+}
+
+// previewRedirect only reports on the write-side of a redirection (">" and
+// ">>"); "<" reads a file rather than touching it, so it's left alone.
+func previewRedirect(c parse.Command) *Result {
+	r := &Result{Action: "write to"}
+	for _, redir := range c.Redirects {
+		if redir.Op != ">" && redir.Op != ">>" {
+			continue
+		}
+		path := expandPath(redir.Target)
+		r.MatchCount++
+		if len(r.Targets) >= maxTreeEntries {
+			r.Truncated = true
+			continue
+		}
+		t := lstatTarget(path)
+		r.Targets = append(r.Targets, t)
+		if redir.Op == ">" && t.Exists {
+			r.Notes = append(r.Notes, fmt.Sprintf("%s already exists and would be truncated", path))
+		}
+	}
+	if len(r.Targets) == 0 {
+		return nil
+	}
+	return r
+}
+
+func merge(into, r *Result) {
+	if into.Action == "" {
+		into.Action = r.Action
+	} else if into.Action != r.Action {
+		into.Action = "modify"
+	}
+	for _, t := range r.Targets {
+		if len(into.Targets) >= maxTreeEntries {
+			into.Truncated = true
+			break
+		}
+		into.Targets = append(into.Targets, t)
+	}
+	into.TotalFiles += r.TotalFiles
+	into.TotalSize += r.TotalSize
+	into.MatchCount += r.MatchCount
+	into.Truncated = into.Truncated || r.Truncated
+	into.Notes = append(into.Notes, r.Notes...)
+}
+
+// pathArgs returns argv[1:] with flags filtered out, honoring a `--`
+// end-of-options marker the way GNU coreutils do.
+func pathArgs(argv []string) []string {
+	var out []string
+	endOfFlags := false
+	for _, a := range argv[1:] {
+		if !endOfFlags && a == "--" {
+			endOfFlags = true
+			continue
+		}
+		if !endOfFlags && a != "-" && strings.HasPrefix(a, "-") {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// resolvePaths expands `~`, env vars, and glob patterns in each raw
+// argument. An argument that matches nothing (a plain path that doesn't
+// exist yet, or a glob with no hits) is kept as its literal expansion so
+// callers can still report it as a nonexistent target.
+func resolvePaths(raws []string) []string {
+	var out []string
+	for _, raw := range raws {
+		expanded := expandPath(raw)
+		matches, err := filepath.Glob(expanded)
+		if err == nil && len(matches) > 0 {
+			out = append(out, matches...)
+			continue
+		}
+		out = append(out, expanded)
+	}
+	return out
+}
+
+// expandPath resolves a leading `~` or `~user` to a home directory and
+// expands $VAR / ${VAR} environment references. It does not touch glob
+// metacharacters; that's left to filepath.Glob.
+func expandPath(raw string) string {
+	switch {
+	case raw == "~":
+		if home, err := os.UserHomeDir(); err == nil {
+			raw = home
+		}
+	case strings.HasPrefix(raw, "~/"):
+		if home, err := os.UserHomeDir(); err == nil {
+			raw = filepath.Join(home, raw[2:])
+		}
+	case strings.HasPrefix(raw, "~"):
+		if idx := strings.IndexByte(raw, '/'); idx > 0 {
+			if u, err := user.Lookup(raw[1:idx]); err == nil {
+				raw = filepath.Join(u.HomeDir, raw[idx+1:])
+			}
+		}
+	}
+	return os.ExpandEnv(raw)
+}
+
+func lstatTarget(path string) Target {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return Target{Path: path}
+	}
+	t := Target{Path: path, Exists: true, IsDir: info.IsDir()}
+	if !info.IsDir() {
+		t.Size = info.Size()
+	}
+	return t
+}
+
+func walkDir(root string) (files int, size int64) {
+	filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		files++
+		return nil
+	})
+	return files, size
+}
+
+// commonDir returns the longest directory shared by every target's path,
+// or "" if there's nothing to summarize.
+func commonDir(targets []Target) string {
+	if len(targets) == 0 {
+		return ""
+	}
+	root := filepath.Dir(targets[0].Path)
+	for _, t := range targets[1:] {
+		root = commonPrefixDir(root, filepath.Dir(t.Path))
+	}
+	return root
+}
+
+func commonPrefixDir(a, b string) string {
+	as := strings.Split(filepath.Clean(a), string(filepath.Separator))
+	bs := strings.Split(filepath.Clean(b), string(filepath.Separator))
+	n := len(as)
+	if len(bs) < n {
+		n = len(bs)
+	}
+	i := 0
+	for i < n && as[i] == bs[i] {
+		i++
+	}
+	if i == 0 {
+		return string(filepath.Separator)
+	}
+	return strings.Join(as[:i], string(filepath.Separator))
+}
@@ -0,0 +1,72 @@
+package dryrun
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summary renders the one-line headline a confirm prompt shows above the
+// detailed tree, e.g. "Would delete 1284 files (2.3 GiB) under
+// /home/me/work/**". It falls back to a plain count when there's no common
+// root worth naming.
+func (r *Result) Summary() string {
+	if !r.Supported {
+		if len(r.Notes) > 0 {
+			return strings.Join(r.Notes, "; ")
+		}
+		return "unable to preview this command's effect"
+	}
+
+	what := fmt.Sprintf("%d file", r.TotalFiles)
+	if r.TotalFiles != 1 {
+		what += "s"
+	}
+	if r.TotalFiles == 0 && len(r.Targets) > 0 {
+		what = fmt.Sprintf("%d path", r.MatchCount)
+		if r.MatchCount != 1 {
+			what += "s"
+		}
+	}
+
+	summary := fmt.Sprintf("Would %s %s (%s)", r.Action, what, formatSize(r.TotalSize))
+	if r.Root != "" && r.Root != "/" {
+		summary += fmt.Sprintf(" under %s/**", r.Root)
+	}
+	return summary
+}
+
+// Tree renders up to maxTreeEntries resolved targets as a flat, indented
+// list, with a trailing "...and N more" line when Truncated.
+func (r *Result) Tree() []string {
+	lines := make([]string, 0, len(r.Targets)+1)
+	for _, t := range r.Targets {
+		marker := "exists"
+		if !t.Exists {
+			marker = "does not exist"
+		} else if t.IsDir {
+			marker = "directory"
+		}
+		line := fmt.Sprintf("  %s (%s)", t.Path, marker)
+		if t.Exists && !t.IsDir && t.Size > 0 {
+			line = fmt.Sprintf("  %s (%s)", t.Path, formatSize(t.Size))
+		}
+		lines = append(lines, line)
+	}
+	if r.Truncated {
+		lines = append(lines, fmt.Sprintf("  ...and %d more", r.MatchCount-len(r.Targets)))
+	}
+	return lines
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
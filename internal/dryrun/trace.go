@@ -0,0 +1,131 @@
+package dryrun
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// sandboxTools are checked in preference order: each runs the command
+// read-only against the real filesystem but inside an isolated mount
+// namespace, so nothing it writes actually lands on disk. firstAvailable
+// picks whichever one is installed.
+var sandboxTools = []struct {
+	name string
+	args func(command string) []string
+}{
+	{"bwrap", func(c string) []string {
+		return []string{"--ro-bind", "/", "/", "--dev", "/dev", "--tmpfs", "/tmp", "--", "/bin/sh", "-c", c}
+	}},
+	{"firejail", func(c string) []string {
+		return []string{"--quiet", "--read-only=/", "--", "/bin/sh", "-c", c}
+	}},
+}
+
+// Trace is the fallback for commands Preview doesn't understand
+// structurally: it re-runs them under whichever sandbox is available with
+// the real filesystem mounted read-only, or failing that under
+// `strace -e trace=file -f`, and reports the paths the command tried to
+// write to. The sandboxed path is genuinely safe - any write fails against
+// the read-only mount - but the strace path has no such guarantee and lets
+// the command execute for real; Result.Notes says which one ran.
+func Trace(command string) (*Result, error) {
+	if tool, args, ok := firstAvailableSandbox(command); ok {
+		return traceUnderSandbox(tool, args)
+	}
+	if _, err := exec.LookPath("strace"); err == nil {
+		return traceUnderStrace(command)
+	}
+	return &Result{
+		Notes: []string{"no sandbox (bwrap/firejail) or strace found; install one to preview unrecognized commands"},
+	}, nil
+}
+
+func firstAvailableSandbox(command string) (name string, args []string, ok bool) {
+	for _, t := range sandboxTools {
+		if _, err := exec.LookPath(t.name); err == nil {
+			return t.name, t.args(command), true
+		}
+	}
+	return "", nil, false
+}
+
+// traceUnderSandbox runs the command for real, but inside a read-only
+// mount of the filesystem - so any attempted write fails with a
+// permission/read-only-filesystem error that tells us what it tried to
+// touch, without anything actually being written.
+func traceUnderSandbox(tool string, args []string) (*Result, error) {
+	cmd := exec.Command(tool, args...)
+	out, _ := cmd.CombinedOutput()
+
+	r := &Result{Action: "modify", Supported: true}
+	r.Notes = append(r.Notes, fmt.Sprintf("traced under %s (read-only filesystem); any write below would have failed here but will succeed for real", tool))
+	for _, path := range readOnlyFailurePaths(string(out)) {
+		r.MatchCount++
+		if len(r.Targets) >= maxTreeEntries {
+			r.Truncated = true
+			continue
+		}
+		r.Targets = append(r.Targets, lstatTarget(path))
+	}
+	return r, nil
+}
+
+var readOnlyFailurePattern = regexp.MustCompile(`(?:cannot (?:create|remove|touch|write to)|Read-only file system).*?['"]?(/[^\s'"]+)`)
+
+func readOnlyFailurePaths(output string) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, line := range strings.Split(output, "\n") {
+		m := readOnlyFailurePattern.FindStringSubmatch(line)
+		if m == nil || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		paths = append(paths, m[1])
+	}
+	return paths
+}
+
+// traceOpenPattern matches the file-write syscalls strace -e trace=file
+// reports, pulling out the path each one targets.
+var traceOpenPattern = regexp.MustCompile(`^(?:open|openat|unlink|unlinkat|rename|renameat2?|mkdir|mkdirat|chmod|chown)\([^)]*?"([^"]+)"`)
+
+// traceUnderStrace runs the command for real under strace, then diffs
+// which file-related syscalls it made. Unlike the sandbox path this does
+// let the command execute - strace has no read-only mode - so it's only
+// used when no sandbox tool is installed.
+func traceUnderStrace(command string) (*Result, error) {
+	cmd := exec.Command("strace", "-f", "-e", "trace=file", "/bin/sh", "-c", command)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	r := &Result{Action: "modify", Supported: true}
+	r.Notes = append(r.Notes, "traced under strace; the command below was actually executed since strace has no dry-run mode")
+
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		m := traceOpenPattern.FindStringSubmatch(scanner.Text())
+		if m == nil || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		r.MatchCount++
+		if len(r.Targets) >= maxTreeEntries {
+			r.Truncated = true
+			continue
+		}
+		r.Targets = append(r.Targets, lstatTarget(m[1]))
+	}
+	cmd.Wait()
+
+	return r, nil
+}
@@ -0,0 +1,109 @@
+// Package exec runs an accepted shell command under a pseudo-terminal,
+// streaming its output live and capturing the exit code, duration, and a
+// tail of output for the "ask AI to fix" feedback loop in internal/cli.
+package exec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// TailLines is how many trailing lines of output are kept for feeding
+// back to the model when a command fails - enough to show the actual
+// error without flooding the prompt.
+const TailLines = 20
+
+// Result is what came of running a command: how it exited, how long it
+// took, and a tail of its output for diagnosing a failure.
+type Result struct {
+	ExitCode int
+	Duration time.Duration
+	Tail     string // last TailLines lines of combined stdout/stderr
+}
+
+// Run executes command under a PTY so interactive programs (progress
+// bars, prompts) render the way they would in a real terminal, copying
+// its output to out as it arrives and returning the captured Result once
+// the process exits. A PTY merges stdout and stderr into one stream, so
+// Tail is a tail of both.
+func Run(command string, out io.Writer) (*Result, error) {
+	cmd := osexec.Command(shellPath(), "-c", command)
+
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("starting command under pty: %w", err)
+	}
+	defer ptmx.Close()
+
+	start := time.Now()
+	tail := newTailBuffer(TailLines)
+	io.Copy(io.MultiWriter(out, tail), ptmx)
+	// A PTY's read side returns an I/O error once the child exits and
+	// closes its end - that's the normal termination signal, not a real
+	// failure, so io.Copy's error is deliberately ignored above.
+
+	waitErr := cmd.Wait()
+	duration := time.Since(start)
+
+	exitCode := 0
+	if waitErr != nil {
+		exitErr, ok := waitErr.(*osexec.ExitError)
+		if !ok {
+			return nil, fmt.Errorf("running command: %w", waitErr)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	return &Result{ExitCode: exitCode, Duration: duration, Tail: tail.String()}, nil
+}
+
+// CapturedResult is the outcome of RunCaptured: like Result, but with
+// stdout and stderr kept separate instead of merged, for callers (JSON
+// batch output) that need to report them individually rather than render
+// them live.
+type CapturedResult struct {
+	ExitCode int
+	Duration time.Duration
+	Stdout   string
+	Stderr   string
+}
+
+// RunCaptured executes command without a PTY, buffering stdout and stderr
+// separately instead of streaming them - appropriate for non-interactive
+// callers (batch/JSON mode) that report the two independently rather than
+// render a live terminal.
+func RunCaptured(command string) (*CapturedResult, error) {
+	cmd := osexec.Command(shellPath(), "-c", command)
+
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	runErr := cmd.Run()
+	duration := time.Since(start)
+
+	exitCode := 0
+	if runErr != nil {
+		exitErr, ok := runErr.(*osexec.ExitError)
+		if !ok {
+			return nil, fmt.Errorf("running command: %w", runErr)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	return &CapturedResult{ExitCode: exitCode, Duration: duration, Stdout: stdout.String(), Stderr: stderr.String()}, nil
+}
+
+func shellPath() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
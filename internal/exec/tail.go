@@ -0,0 +1,48 @@
+package exec
+
+import "strings"
+
+// tailBuffer is an io.Writer that keeps only the last max lines written
+// to it, so capturing a bounded tail of a command's output doesn't
+// require holding all of it in memory.
+type tailBuffer struct {
+	max   int
+	lines []string
+	cur   strings.Builder
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\n' {
+			t.pushLine(t.cur.String())
+			t.cur.Reset()
+			continue
+		}
+		t.cur.WriteByte(b)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) pushLine(line string) {
+	t.lines = append(t.lines, line)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+}
+
+// String returns every complete line currently held, plus whatever
+// partial line hasn't seen a trailing newline yet.
+func (t *tailBuffer) String() string {
+	lines := t.lines
+	if t.cur.Len() > 0 {
+		lines = append(append([]string{}, lines...), t.cur.String())
+		if len(lines) > t.max {
+			lines = lines[len(lines)-t.max:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
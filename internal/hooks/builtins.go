@@ -0,0 +1,79 @@
+package hooks
+
+import (
+	"fmt"
+	"log/syslog"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// builtinFunc runs a built-in hook against one Event, returning its
+// Decision the same way an external hook's stdout would.
+type builtinFunc func(Event) (Decision, error)
+
+// builtins are hooks usable from hooks.yaml's `builtin:` field without
+// shelling out to an external executable.
+var builtins = map[string]builtinFunc{
+	"redact_secrets":    redactSecrets,
+	"require_git_clean": requireGitClean,
+	"log_to_syslog":     logToSyslog,
+	"record_asciicast":  recordAsciicast,
+}
+
+var secretPattern = regexp.MustCompile(`(?i)(api[_-]?key|token|secret|password)=\S+`)
+
+// redactSecrets records a copy of the command with obvious secret-shaped
+// arguments masked, as a "redacted_command" annotation. It never mutates
+// the command itself, so the command that runs, the one later hooks see,
+// and the audit entry's Command field all still carry the secret.
+func redactSecrets(event Event) (Decision, error) {
+	redacted := secretPattern.ReplaceAllString(event.Command, "$1=***")
+	if redacted == event.Command {
+		return Decision{}, nil
+	}
+	return Decision{Annotations: map[string]string{"redacted_command": redacted}}, nil
+}
+
+// requireGitClean vetoes pre_execute when Cwd has uncommitted changes, for
+// sites that don't want file-modifying commands run against a dirty tree.
+// A directory that isn't a git repo (or has no git installed) has nothing
+// to require, so it's a no-op rather than a veto.
+func requireGitClean(event Event) (Decision, error) {
+	out, err := exec.Command("git", "-C", event.Cwd, "status", "--porcelain").Output()
+	if err != nil {
+		return Decision{}, nil
+	}
+	if strings.TrimSpace(string(out)) != "" {
+		return Decision{Veto: true, Reason: "working tree has uncommitted changes"}, nil
+	}
+	return Decision{}, nil
+}
+
+// logToSyslog reports the event to the local syslog daemon, best-effort -
+// a host with no syslog listener (common on a laptop) makes this a no-op
+// rather than a pipeline failure.
+func logToSyslog(event Event) (Decision, error) {
+	w, err := syslog.New(syslog.LOG_INFO, "x")
+	if err != nil {
+		return Decision{}, nil
+	}
+	defer w.Close()
+	fmt.Fprintf(w, "%s: %s", event.Phase, event.Command)
+	return Decision{}, nil
+}
+
+// recordAsciicast appends the event to ~/.x/asciicast.jsonl, one line per
+// lifecycle phase, as a lightweight session transcript a post_execute
+// hook chain can build incrementally without a dedicated recorder
+// process.
+func recordAsciicast(event Event) (Decision, error) {
+	path, err := asciicastPath()
+	if err != nil {
+		return Decision{}, err
+	}
+	if err := appendJSONLine(path, event); err != nil {
+		return Decision{}, err
+	}
+	return Decision{}, nil
+}
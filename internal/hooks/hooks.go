@@ -0,0 +1,248 @@
+// Package hooks runs a configurable pipeline of pre/post generation and
+// execution hooks - external executables or built-ins - so policy (secret
+// redaction, a clean-tree requirement, audit logging) lives in
+// ~/.x/hooks.yaml instead of being hardcoded into the cli package, the
+// same dependency-injected-policy shape tools like syft use for scanners.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Phase is one point in the command lifecycle a hook can attach to.
+type Phase string
+
+const (
+	PhasePreGenerate  Phase = "pre_generate"
+	PhasePostGenerate Phase = "post_generate"
+	PhasePreExecute   Phase = "pre_execute"
+	PhasePostExecute  Phase = "post_execute"
+)
+
+// EnvAllowlist is the set of environment variables forwarded to external
+// hooks (both as Event.Env and as the subprocess's own environment), kept
+// short deliberately so a hook script never sees a secret it didn't ask
+// for by name.
+var EnvAllowlist = []string{"HOME", "USER", "SHELL", "PWD", "PATH"}
+
+// Event is the JSON payload sent to an external hook on stdin, and passed
+// to a built-in directly. It's deliberately flat and serializable so an
+// external hook and an audit.Entry can share the same shape.
+type Event struct {
+	Phase       Phase             `json:"phase"`
+	Instruction string            `json:"instruction,omitempty"`
+	Command     string            `json:"command,omitempty"`
+	Provider    string            `json:"provider,omitempty"`
+	Model       string            `json:"model,omitempty"`
+	Cwd         string            `json:"cwd,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	ExitCode    int               `json:"exit_code,omitempty"`
+}
+
+// NewEvent builds an Event for phase, filling Cwd and Env (per
+// EnvAllowlist) from the current process.
+func NewEvent(phase Phase, instruction, command, provider, model string) Event {
+	cwd, _ := os.Getwd()
+	env := make(map[string]string, len(EnvAllowlist))
+	for _, key := range EnvAllowlist {
+		if v, ok := os.LookupEnv(key); ok {
+			env[key] = v
+		}
+	}
+	return Event{
+		Phase:       phase,
+		Instruction: instruction,
+		Command:     command,
+		Provider:    provider,
+		Model:       model,
+		Cwd:         cwd,
+		Env:         env,
+	}
+}
+
+// Decision is what a hook - external or built-in - reports back: whether
+// to veto the lifecycle it ran in, an optional mutated command for later
+// hooks (and the caller) to see instead, and free-form annotations for
+// the audit log.
+type Decision struct {
+	Veto        bool              `json:"veto,omitempty"`
+	Reason      string            `json:"reason,omitempty"`
+	Command     string            `json:"command,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Hook is a single configured hook: either an external executable invoked
+// per event, or one of the built-ins registered in builtins.go. Exactly
+// one of Run/Builtin should be set.
+type Hook struct {
+	Phase   Phase  `yaml:"phase"`
+	Run     string `yaml:"run,omitempty"`
+	Builtin string `yaml:"builtin,omitempty"`
+}
+
+// fileConfig is the shape of ~/.x/hooks.yaml.
+type fileConfig struct {
+	Hooks []Hook `yaml:"hooks"`
+}
+
+// Pipeline runs every hook configured for a phase, in the order they
+// appear in hooks.yaml.
+type Pipeline struct {
+	hooks []Hook
+}
+
+// DefaultPath returns ~/.x/hooks.yaml, the conventional location for a
+// site's hook configuration.
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".x", "hooks.yaml"), nil
+}
+
+// Load reads a Pipeline from the YAML file at path. A missing file is not
+// an error - it just means no hooks are configured.
+func Load(path string) (*Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Pipeline{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg fileConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing hooks config %s: %w", path, err)
+	}
+	return &Pipeline{hooks: cfg.Hooks}, nil
+}
+
+// Run executes every hook registered for event.Phase in order, stopping at
+// the first veto. A hook's mutated Command becomes the Command the next
+// hook (and the caller, via the returned command) sees, so hooks compose.
+func (p *Pipeline) Run(ctx context.Context, event Event) (command string, decisions []Decision, err error) {
+	command = event.Command
+	for _, h := range p.hooks {
+		if h.Phase != event.Phase {
+			continue
+		}
+
+		decision, err := p.runOne(ctx, h, event)
+		if err != nil {
+			return command, decisions, fmt.Errorf("hook %s: %w", hookName(h), err)
+		}
+		decisions = append(decisions, decision)
+		if decision.Command != "" {
+			command = decision.Command
+			event.Command = decision.Command
+		}
+		if decision.Veto {
+			break
+		}
+	}
+	return command, decisions, nil
+}
+
+func (p *Pipeline) runOne(ctx context.Context, h Hook, event Event) (Decision, error) {
+	if h.Builtin != "" {
+		fn, ok := builtins[h.Builtin]
+		if !ok {
+			return Decision{}, fmt.Errorf("unknown builtin %q", h.Builtin)
+		}
+		return fn(event)
+	}
+	return runExternal(ctx, h.Run, event)
+}
+
+func hookName(h Hook) string {
+	if h.Builtin != "" {
+		return h.Builtin
+	}
+	return h.Run
+}
+
+// runExternal invokes an external hook, writing event as JSON to its
+// stdin and parsing a Decision from its stdout. A hook that prints
+// nothing is treated as a no-op decision.
+func runExternal(ctx context.Context, command string, event Event) (Decision, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = allowedEnv()
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return Decision{}, err
+	}
+
+	if strings.TrimSpace(out.String()) == "" {
+		return Decision{}, nil
+	}
+
+	var decision Decision
+	if err := json.Unmarshal(out.Bytes(), &decision); err != nil {
+		return Decision{}, fmt.Errorf("parsing hook output: %w", err)
+	}
+	return decision, nil
+}
+
+// allowedEnv builds the environment passed to an external hook's process
+// from EnvAllowlist, so a hook script never inherits the full process
+// environment (provider API keys, etc.) unless explicitly widened.
+func allowedEnv() []string {
+	var env []string
+	for _, key := range EnvAllowlist {
+		if v, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		}
+	}
+	return env
+}
+
+// asciicastPath returns ~/.x/asciicast.jsonl, the recordAsciicast builtin's
+// output file.
+func asciicastPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".x", "asciicast.jsonl"), nil
+}
+
+// appendJSONLine marshals v and appends it as one line to the file at
+// path, creating both the file and its parent directory if needed.
+func appendJSONLine(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
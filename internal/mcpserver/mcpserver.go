@@ -0,0 +1,467 @@
+// Package mcpserver implements a Model Context Protocol server over
+// stdio: line-delimited JSON-RPC 2.0 requests in, responses out. It
+// advertises four tools - generate_command, refine_command,
+// explain_command, and execute_command - backed by the same
+// provider.Provider interface and safety classifier the interactive CLI
+// uses, so an MCP client (Claude Desktop, an editor, an agentic tool)
+// gets identical behavior without a separate integration living outside
+// the cli package.
+package mcpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/exec"
+	"github.com/REDFOX1899/ask-sh/internal/prompt"
+	"github.com/REDFOX1899/ask-sh/internal/provider"
+	"github.com/REDFOX1899/ask-sh/internal/safety"
+)
+
+// protocolVersion is the MCP protocol version this server speaks, echoed
+// back verbatim in the initialize response.
+const protocolVersion = "2024-11-05"
+
+// Server serves the MCP stdio protocol, resolving each tool call's
+// provider through Registry/Cfg the same way the CLI's resolveProvider
+// does: an explicit "model" argument names a ~/.x/models/ alias,
+// otherwise the registry auto-detects.
+type Server struct {
+	Registry *provider.Registry
+	Cfg      *config.Config
+	In       io.Reader
+	Out      io.Writer
+}
+
+// New returns a Server wired to registry/cfg, reading requests from in
+// and writing responses to out - typically os.Stdin/os.Stdout, the MCP
+// stdio transport.
+func New(registry *provider.Registry, cfg *config.Config, in io.Reader, out io.Writer) *Server {
+	return &Server{Registry: registry, Cfg: cfg, In: in, Out: out}
+}
+
+// rpcRequest is one JSON-RPC 2.0 request. ID is carried as a raw message
+// so it round-trips into the response untouched whether the client sent
+// a number, a string, or omitted it (a notification, which gets no
+// response).
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC error codes per the spec; MCP doesn't define its own.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// Serve reads one JSON-RPC request per line from s.In until EOF, writing
+// one response per line to s.Out. A line that isn't valid JSON gets a
+// parse-error response rather than aborting the whole session, so one
+// malformed request doesn't kill a long-lived client connection.
+func (s *Server) Serve() error {
+	scanner := bufio.NewScanner(s.In)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.writeResponse(rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+
+		resp := s.handle(req)
+		if req.ID == nil {
+			// A request with no ID is a notification; the spec forbids a
+			// response to it.
+			continue
+		}
+		resp.ID = req.ID
+		s.writeResponse(resp)
+	}
+	return scanner.Err()
+}
+
+func (s *Server) writeResponse(resp rpcResponse) {
+	resp.JSONRPC = "2.0"
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(s.Out, "%s\n", data)
+}
+
+func (s *Server) handle(req rpcRequest) rpcResponse {
+	switch req.Method {
+	case "initialize":
+		return rpcResponse{Result: initializeResult{
+			ProtocolVersion: protocolVersion,
+			Capabilities:    capabilities{Tools: &struct{}{}},
+			ServerInfo:      serverInfo{Name: "x", Version: "1.0.0"},
+		}}
+	case "tools/list":
+		return rpcResponse{Result: toolsListResult{Tools: toolDefinitions}}
+	case "tools/call":
+		return s.handleToolCall(req.Params)
+	default:
+		return rpcResponse{Error: &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}}
+	}
+}
+
+type initializeResult struct {
+	ProtocolVersion string       `json:"protocolVersion"`
+	Capabilities    capabilities `json:"capabilities"`
+	ServerInfo      serverInfo   `json:"serverInfo"`
+}
+
+type capabilities struct {
+	// Tools is present (and empty) to advertise tool support without
+	// declaring any sub-capabilities (e.g. list-changed notifications)
+	// this server doesn't implement.
+	Tools *struct{} `json:"tools"`
+}
+
+type serverInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type toolsListResult struct {
+	Tools []toolDefinition `json:"tools"`
+}
+
+// toolDefinition describes one callable tool in the shape tools/list
+// expects: a name, a human description, and a JSON Schema for its
+// arguments.
+type toolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+var toolDefinitions = []toolDefinition{
+	{
+		Name:        "generate_command",
+		Description: "Generate a shell command from a natural language instruction.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"instruction": stringProp("The natural language instruction to turn into a shell command."),
+			"model":       stringProp("Optional model alias from ~/.x/models/; auto-detected if omitted."),
+		}, "instruction"),
+	},
+	{
+		Name:        "refine_command",
+		Description: "Refine a previously generated shell command based on follow-up feedback.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"command":    stringProp("The command to refine."),
+			"refinement": stringProp("What to change about the command."),
+			"model":      stringProp("Optional model alias from ~/.x/models/; auto-detected if omitted."),
+		}, "command", "refinement"),
+	},
+	{
+		Name:        "explain_command",
+		Description: "Explain what a shell command does in plain language.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"command": stringProp("The command to explain."),
+			"model":   stringProp("Optional model alias from ~/.x/models/; auto-detected if omitted."),
+		}, "command"),
+	},
+	{
+		Name:        "execute_command",
+		Description: "Execute a shell command and return its output. Commands the safety classifier rates above SAFE are refused unless confirm is true.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"command": stringProp("The command to execute."),
+			"confirm": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Must be true to execute a command the safety classifier rates above SAFE.",
+			},
+		}, "command"),
+	},
+}
+
+func stringProp(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// toolContent is one block of an MCP tool result, always "text" here -
+// none of these four tools return images or other media.
+type toolContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// toolResult is the result shape tools/call returns: free-form content
+// plus an isError flag the client uses to distinguish a tool-level
+// failure (bad args, refused execution) from a genuine transport error.
+type toolResult struct {
+	Content []toolContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+func textResult(text string) toolResult {
+	return toolResult{Content: []toolContent{{Type: "text", Text: text}}}
+}
+
+func errorResult(format string, args ...interface{}) toolResult {
+	return toolResult{Content: []toolContent{{Type: "text", Text: fmt.Sprintf(format, args...)}}, IsError: true}
+}
+
+type toolCallParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+func (s *Server) handleToolCall(raw json.RawMessage) rpcResponse {
+	var params toolCallParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return rpcResponse{Error: &rpcError{Code: codeInvalidParams, Message: err.Error()}}
+	}
+
+	ctx := context.Background()
+	var result toolResult
+	switch params.Name {
+	case "generate_command":
+		result = s.callGenerateCommand(ctx, params.Arguments)
+	case "refine_command":
+		result = s.callRefineCommand(ctx, params.Arguments)
+	case "explain_command":
+		result = s.callExplainCommand(ctx, params.Arguments)
+	case "execute_command":
+		result = s.callExecuteCommand(params.Arguments)
+	default:
+		return rpcResponse{Error: &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("unknown tool %q", params.Name)}}
+	}
+	return rpcResponse{Result: result}
+}
+
+// resolveProvider picks the provider for a tool call the same way the
+// CLI's own resolveProvider does: an explicit model alias if given,
+// otherwise whatever the registry auto-detects.
+func (s *Server) resolveProvider(model string) (provider.Provider, string, error) {
+	if model != "" {
+		p, err := s.Registry.ResolveModel(model)
+		if err != nil {
+			return nil, "", err
+		}
+		return p, s.Cfg.Models[model].PromptTemplate, nil
+	}
+	p, err := s.Registry.Detect()
+	if err != nil {
+		return nil, "", err
+	}
+	return p, "", nil
+}
+
+type generateCommandArgs struct {
+	Instruction string `json:"instruction"`
+	Model       string `json:"model"`
+}
+
+// generateCommandResult mirrors provider.Response's fields relevant to a
+// caller that only sees the final command, not the cli package's richer
+// in-process Response.
+type generateCommandResult struct {
+	Command      string `json:"command"`
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`
+	Explanation  string `json:"explanation,omitempty"`
+	Danger       string `json:"danger,omitempty"`
+	RequiresSudo bool   `json:"requires_sudo,omitempty"`
+	RiskLevel    string `json:"risk_level"`
+}
+
+func (s *Server) callGenerateCommand(ctx context.Context, raw json.RawMessage) toolResult {
+	var args generateCommandArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return errorResult("invalid arguments: %v", err)
+	}
+	if args.Instruction == "" {
+		return errorResult("instruction is required")
+	}
+
+	p, promptTemplate, err := s.resolveProvider(args.Model)
+	if err != nil {
+		return errorResult("resolving provider: %v", err)
+	}
+
+	promptText, err := prompt.Build(args.Instruction, promptTemplate, "")
+	if err != nil {
+		return errorResult("building prompt: %v", err)
+	}
+
+	resp, err := p.GenerateCommand(ctx, promptText)
+	if err != nil {
+		return errorResult("generating command: %v", err)
+	}
+
+	assessment := safety.AnalyzeCommand(resp.Command)
+	return jsonTextResult(generateCommandResult{
+		Command:      resp.Command,
+		Provider:     resp.Provider,
+		Model:        resp.Model,
+		Explanation:  resp.Explanation,
+		Danger:       resp.Danger,
+		RequiresSudo: resp.RequiresSudo,
+		RiskLevel:    safety.GetRiskLevelName(assessment.Level),
+	})
+}
+
+type refineCommandArgs struct {
+	Command    string `json:"command"`
+	Refinement string `json:"refinement"`
+	Model      string `json:"model"`
+}
+
+func (s *Server) callRefineCommand(ctx context.Context, raw json.RawMessage) toolResult {
+	var args refineCommandArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return errorResult("invalid arguments: %v", err)
+	}
+	if args.Command == "" || args.Refinement == "" {
+		return errorResult("command and refinement are required")
+	}
+
+	p, _, err := s.resolveProvider(args.Model)
+	if err != nil {
+		return errorResult("resolving provider: %v", err)
+	}
+
+	resp, err := p.RefineCommand(ctx, args.Command, args.Refinement)
+	if err != nil {
+		return errorResult("refining command: %v", err)
+	}
+
+	assessment := safety.AnalyzeCommand(resp.Command)
+	return jsonTextResult(generateCommandResult{
+		Command:      resp.Command,
+		Provider:     resp.Provider,
+		Model:        resp.Model,
+		Explanation:  resp.Explanation,
+		Danger:       resp.Danger,
+		RequiresSudo: resp.RequiresSudo,
+		RiskLevel:    safety.GetRiskLevelName(assessment.Level),
+	})
+}
+
+type explainCommandArgs struct {
+	Command string `json:"command"`
+	Model   string `json:"model"`
+}
+
+func (s *Server) callExplainCommand(ctx context.Context, raw json.RawMessage) toolResult {
+	var args explainCommandArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return errorResult("invalid arguments: %v", err)
+	}
+	if args.Command == "" {
+		return errorResult("command is required")
+	}
+
+	p, _, err := s.resolveProvider(args.Model)
+	if err != nil {
+		return errorResult("resolving provider: %v", err)
+	}
+
+	explanation, err := p.ExplainCommand(ctx, args.Command)
+	if err != nil {
+		return errorResult("explaining command: %v", err)
+	}
+	return textResult(explanation)
+}
+
+type executeCommandArgs struct {
+	Command string `json:"command"`
+	Confirm bool   `json:"confirm"`
+}
+
+// executeCommandResult mirrors exec.CapturedResult with the risk
+// assessment that gated (or would have gated) the execution.
+type executeCommandResult struct {
+	Command    string `json:"command"`
+	RiskLevel  string `json:"risk_level"`
+	ExitCode   int    `json:"exit_code"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// callExecuteCommand runs args.Command non-interactively via
+// exec.RunCaptured, the same way --stdin/--yes batch mode does, honoring
+// the safety classifier: a denied command is always refused, and one
+// rated above SAFE requires confirm: true since there's no TUI here to
+// ask the caller.
+func (s *Server) callExecuteCommand(raw json.RawMessage) toolResult {
+	var args executeCommandArgs
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return errorResult("invalid arguments: %v", err)
+	}
+	if args.Command == "" {
+		return errorResult("command is required")
+	}
+
+	assessment, err := safety.Gate(args.Command, "")
+	if err != nil {
+		return errorResult("refusing to run %q: %v", args.Command, err)
+	}
+	if assessment.Level > safety.RiskNone && !args.Confirm {
+		return errorResult("command is rated %s; pass confirm: true to execute it", safety.GetRiskLevelName(assessment.Level))
+	}
+
+	captured, err := exec.RunCaptured(args.Command)
+	if err != nil {
+		return errorResult("running command: %v", err)
+	}
+
+	return jsonTextResult(executeCommandResult{
+		Command:    args.Command,
+		RiskLevel:  safety.GetRiskLevelName(assessment.Level),
+		ExitCode:   captured.ExitCode,
+		Stdout:     captured.Stdout,
+		Stderr:     captured.Stderr,
+		DurationMS: captured.Duration.Milliseconds(),
+	})
+}
+
+// jsonTextResult marshals v as the text of a single content block, since
+// MCP tool results carry text/image/resource blocks, not arbitrary JSON -
+// a client that wants structured data parses the text as JSON itself.
+func jsonTextResult(v interface{}) toolResult {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errorResult("marshaling result: %v", err)
+	}
+	return textResult(string(data))
+}
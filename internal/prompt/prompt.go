@@ -1,31 +1,73 @@
 package prompt
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"runtime"
+	"text/template"
 )
 
-// Build creates the system prompt for the AI model
-func Build(instruction string) string {
-	return fmt.Sprintf(`You are a shell command generator. Convert the user's natural language instruction into a shell command.
+// Vars are the fields available to a prompt template.
+type Vars struct {
+	Instruction string
+	OS          string
+	Shell       string
+	Cwd         string
+	EnvContext  string
+}
+
+const defaultTemplate = `You are a shell command generator. Convert the user's natural language instruction into a shell command.
 
 Rules:
 - Return ONLY the shell command, nothing else
 - No explanations, no markdown formatting, no code block markers
-- No backticks, no `+"`"+`bash`+"`"+`, no comments
+- No backticks, no ` + "`" + `bash` + "`" + `, no comments
 - Just the raw executable command(s)
 - Use pipes (|) and operators (&&, ||) as needed
 - If multiple commands are needed, combine them with && or ;
 
 Context:
-- Current directory: %s
-- Shell: %s
-- OS: %s
+- Current directory: {{.Cwd}}
+- Shell: {{.Shell}}
+- OS: {{.OS}}
+{{if .EnvContext}}
+{{.EnvContext}}
+{{end}}
+Instruction: {{.Instruction}}
+
+Command:`
 
-Instruction: %s
+// Build renders the system prompt for the AI model. If tmplText is empty,
+// the default template is used; otherwise tmplText is parsed as a Go
+// text/template executed against Vars (e.g. a model alias's
+// prompt_template). envContext, when non-empty, is typically the output
+// of context.GetContext(...).Format(...) and is rendered verbatim into
+// the EnvContext template field; pass "" to omit it.
+func Build(instruction, tmplText, envContext string) (string, error) {
+	if tmplText == "" {
+		tmplText = defaultTemplate
+	}
+
+	tmpl, err := template.New("prompt").Parse(tmplText)
+	if err != nil {
+		return "", fmt.Errorf("parsing prompt template: %w", err)
+	}
+
+	vars := Vars{
+		Instruction: instruction,
+		OS:          getOS(),
+		Shell:       getShell(),
+		Cwd:         getCurrentDir(),
+		EnvContext:  envContext,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("executing prompt template: %w", err)
+	}
 
-Command:`, getCurrentDir(), getShell(), getOS(), instruction)
+	return buf.String(), nil
 }
 
 // getCurrentDir returns the current working directory
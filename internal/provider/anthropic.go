@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -11,13 +12,16 @@ import (
 	"strings"
 
 	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/provider/transport"
+	"github.com/REDFOX1899/ask-sh/internal/schema"
 )
 
 // Anthropic provider implementation
 type Anthropic struct {
-	apiKey  string
-	models  []string
-	verbose bool
+	apiKey    string
+	models    []string
+	verbose   bool
+	transport transport.Transport
 }
 
 // Anthropic API request/response types
@@ -25,6 +29,18 @@ type anthropicRequest struct {
 	Model     string             `json:"model"`
 	MaxTokens int                `json:"max_tokens"`
 	Messages  []anthropicMessage `json:"messages"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+// anthropicStreamEvent is a single SSE `data: {...}` frame from the
+// streaming messages endpoint. Only the fields needed to extract text
+// deltas and detect completion are modeled.
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error *anthropicError `json:"error,omitempty"`
 }
 
 type anthropicMessage struct {
@@ -34,10 +50,14 @@ type anthropicMessage struct {
 
 type anthropicResponse struct {
 	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		ID    string          `json:"id,omitempty"`
+		Name  string          `json:"name,omitempty"`
+		Input json.RawMessage `json:"input,omitempty"`
 	} `json:"content"`
-	Error *anthropicError `json:"error,omitempty"`
+	StopReason string          `json:"stop_reason,omitempty"`
+	Error      *anthropicError `json:"error,omitempty"`
 }
 
 type anthropicError struct {
@@ -45,6 +65,50 @@ type anthropicError struct {
 	Message string `json:"message"`
 }
 
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// anthropicContentBlock is one block of a tool-calling message's content
+// array: a plain text block, a tool_use block the model emits, or a
+// tool_result block the caller sends back.
+type anthropicContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicToolMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicToolRequest struct {
+	Model     string                 `json:"model"`
+	MaxTokens int                    `json:"max_tokens"`
+	Messages  []anthropicToolMessage `json:"messages"`
+	Tools     []anthropicTool        `json:"tools"`
+}
+
+type anthropicStructuredRequest struct {
+	Model      string              `json:"model"`
+	MaxTokens  int                 `json:"max_tokens"`
+	Messages   []anthropicMessage  `json:"messages"`
+	Tools      []anthropicTool     `json:"tools"`
+	ToolChoice anthropicToolChoice `json:"tool_choice"`
+}
+
 // NewAnthropic creates a new Anthropic provider
 func NewAnthropic(cfg *config.Config, verbose bool) *Anthropic {
 	models := []string{cfg.AnthropicModel}
@@ -57,12 +121,19 @@ func NewAnthropic(cfg *config.Config, verbose bool) *Anthropic {
 	}
 
 	return &Anthropic{
-		apiKey:  cfg.AnthropicAPIKey,
-		models:  models,
-		verbose: verbose,
+		apiKey:    cfg.AnthropicAPIKey,
+		models:    models,
+		verbose:   verbose,
+		transport: transport.Default,
 	}
 }
 
+// SetTransport overrides the HTTP transport used for API requests, for
+// offline/deterministic runs against a recorded cassette.
+func (a *Anthropic) SetTransport(t transport.Transport) {
+	a.transport = t
+}
+
 // Name returns the provider name
 func (a *Anthropic) Name() string {
 	return "anthropic"
@@ -73,6 +144,87 @@ func (a *Anthropic) IsAvailable() bool {
 	return a.apiKey != ""
 }
 
+// PrimaryModel returns the model StreamCommand uses.
+func (a *Anthropic) PrimaryModel() string {
+	return a.models[0]
+}
+
+// SetModel overrides the primary model, keeping the existing fallback
+// chain behind it.
+func (a *Anthropic) SetModel(model string) {
+	a.models = append([]string{model}, a.models...)
+}
+
+// StreamCommand streams the model's response over SSE.
+func (a *Anthropic) StreamCommand(ctx context.Context, prompt string) (<-chan Token, error) {
+	reqBody := anthropicRequest{
+		Model:     a.models[0],
+		MaxTokens: 500,
+		Messages: []anthropicMessage{
+			{Role: "user", Content: prompt},
+		},
+		Stream: true,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", a.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := a.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make(chan Token)
+	go func() {
+		defer resp.Body.Close()
+		defer close(tokens)
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+
+			var event anthropicStreamEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				continue
+			}
+			if event.Error != nil {
+				tokens <- Token{Err: fmt.Errorf("%w: %s", ErrAPIFailure, event.Error.Message)}
+				return
+			}
+			switch event.Type {
+			case "content_block_delta":
+				if event.Delta.Text != "" {
+					tokens <- Token{Text: event.Delta.Text}
+				}
+			case "message_stop":
+				tokens <- Token{Done: true}
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			tokens <- Token{Err: err}
+		}
+	}()
+
+	return tokens, nil
+}
+
 // GenerateCommand generates a shell command using Anthropic
 func (a *Anthropic) GenerateCommand(ctx context.Context, prompt string) (*Response, error) {
 	var lastErr error
@@ -104,12 +256,18 @@ func (a *Anthropic) GenerateCommand(ctx context.Context, prompt string) (*Respon
 }
 
 func (a *Anthropic) callAPI(ctx context.Context, model, prompt string) (*Response, error) {
+	return a.callAPIMessages(ctx, model, []anthropicMessage{
+		{Role: "user", Content: prompt},
+	})
+}
+
+// callAPIMessages sends a full message history in a single turn, used by
+// both callAPI (a single user message) and GenerateWithHistory.
+func (a *Anthropic) callAPIMessages(ctx context.Context, model string, messages []anthropicMessage) (*Response, error) {
 	reqBody := anthropicRequest{
 		Model:     model,
 		MaxTokens: 500,
-		Messages: []anthropicMessage{
-			{Role: "user", Content: prompt},
-		},
+		Messages:  messages,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -130,8 +288,7 @@ func (a *Anthropic) callAPI(ctx context.Context, model, prompt string) (*Respons
 	req.Header.Set("x-api-key", a.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := a.transport.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +333,206 @@ func (a *Anthropic) callAPI(ctx context.Context, model, prompt string) (*Respons
 	}, nil
 }
 
+// GenerateWithHistory generates a command from a full conversation history
+// instead of a single prompt, so multi-turn refinement carries context
+// from every prior turn rather than just the latest one.
+func (a *Anthropic) GenerateWithHistory(ctx context.Context, history []HistoryMessage) (*Response, error) {
+	messages := make([]anthropicMessage, len(history))
+	for i, h := range history {
+		messages[i] = anthropicMessage{Role: h.Role, Content: h.Content}
+	}
+
+	var lastErr error
+	for _, model := range a.models {
+		resp, err := a.callAPIMessages(ctx, model, messages)
+		if err != nil {
+			if err == ErrModelNotFound {
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrAPIFailure
+}
+
+// GenerateWithTools sends history plus tool definitions using Anthropic's
+// native tool_use API, and returns either the model's final text answer or
+// the tool calls it wants executed. Tool results in history (Role ==
+// "tool") are sent back as "user" messages containing a tool_result block,
+// per Anthropic's convention.
+func (a *Anthropic) GenerateWithTools(ctx context.Context, history []HistoryMessage, tools []Tool) (*ToolResponse, error) {
+	messages := make([]anthropicToolMessage, len(history))
+	for i, h := range history {
+		switch h.Role {
+		case "tool":
+			messages[i] = anthropicToolMessage{
+				Role: "user",
+				Content: []anthropicContentBlock{
+					{Type: "tool_result", ToolUseID: h.ToolCallID, Content: h.Content},
+				},
+			}
+		case "assistant":
+			var blocks []anthropicContentBlock
+			if h.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: h.Content})
+			}
+			for _, tc := range h.ToolCalls {
+				input, _ := json.Marshal(tc.Arguments)
+				blocks = append(blocks, anthropicContentBlock{Type: "tool_use", ID: tc.ID, Name: tc.Name, Input: input})
+			}
+			messages[i] = anthropicToolMessage{Role: "assistant", Content: blocks}
+		default:
+			messages[i] = anthropicToolMessage{
+				Role:    "user",
+				Content: []anthropicContentBlock{{Type: "text", Text: h.Content}},
+			}
+		}
+	}
+
+	anthropicTools := make([]anthropicTool, len(tools))
+	for i, t := range tools {
+		anthropicTools[i] = anthropicTool{Name: t.Name, Description: t.Description, InputSchema: t.Parameters}
+	}
+
+	reqBody := anthropicToolRequest{
+		Model:     a.models[0],
+		MaxTokens: 1024,
+		Messages:  messages,
+		Tools:     anthropicTools,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", a.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := a.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result anthropicResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAPIFailure, result.Error.Message)
+	}
+
+	var calls []ToolCall
+	var text strings.Builder
+	for _, block := range result.Content {
+		switch block.Type {
+		case "tool_use":
+			var args map[string]interface{}
+			if err := json.Unmarshal(block.Input, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse tool call arguments: %w", err)
+			}
+			calls = append(calls, ToolCall{ID: block.ID, Name: block.Name, Arguments: args})
+		case "text":
+			text.WriteString(block.Text)
+		}
+	}
+
+	if len(calls) > 0 {
+		return &ToolResponse{ToolCalls: calls}, nil
+	}
+	return &ToolResponse{Text: strings.TrimSpace(text.String())}, nil
+}
+
+// GenerateStructuredCommand generates a command using forced tool-use so
+// the result is guaranteed to parse as schema.CommandResult.
+func (a *Anthropic) GenerateStructuredCommand(ctx context.Context, prompt string) (*Response, error) {
+	model := a.models[0]
+	reqBody := anthropicStructuredRequest{
+		Model:     model,
+		MaxTokens: 500,
+		Messages: []anthropicMessage{
+			{Role: "user", Content: prompt},
+		},
+		Tools: []anthropicTool{
+			{Name: schema.Name, Description: schema.Description, InputSchema: schema.JSONSchema},
+		},
+		ToolChoice: anthropicToolChoice{Type: "tool", Name: schema.Name},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", a.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := a.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result anthropicResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAPIFailure, result.Error.Message)
+	}
+
+	for _, block := range result.Content {
+		if block.Type != "tool_use" || block.Name != schema.Name {
+			continue
+		}
+		var cr schema.CommandResult
+		if err := json.Unmarshal(block.Input, &cr); err != nil {
+			return nil, fmt.Errorf("failed to parse structured response: %w", err)
+		}
+		return &Response{
+			Command:      strings.TrimSpace(cr.Command),
+			Model:        model,
+			Provider:     a.Name(),
+			Explanation:  cr.Explanation,
+			Danger:       cr.Danger,
+			RequiresSudo: cr.RequiresSudo,
+		}, nil
+	}
+
+	return nil, ErrEmptyResponse
+}
+
 // ExplainCommand explains what a shell command does
 func (a *Anthropic) ExplainCommand(ctx context.Context, command string) (string, error) {
 	prompt := fmt.Sprintf(`Explain this shell command in simple terms. Break down each flag and option.
@@ -234,8 +591,7 @@ func (a *Anthropic) callAPISimple(ctx context.Context, model, prompt string, max
 	req.Header.Set("x-api-key", a.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := a.transport.Do(req)
 	if err != nil {
 		return "", err
 	}
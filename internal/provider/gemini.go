@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -11,39 +12,73 @@ import (
 	"strings"
 
 	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/provider/transport"
+	"github.com/REDFOX1899/ask-sh/internal/schema"
 )
 
 // Gemini provider implementation
 type Gemini struct {
-	apiKey  string
-	models  []string
-	verbose bool
+	apiKey    string
+	models    []string
+	verbose   bool
+	transport transport.Transport
 }
 
 // Gemini API request/response types
 type geminiRequest struct {
-	Contents         []geminiContent  `json:"contents"`
-	GenerationConfig geminiGenConfig  `json:"generationConfig"`
+	Contents         []geminiContent `json:"contents"`
+	GenerationConfig geminiGenConfig `json:"generationConfig"`
 }
 
 type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
 	Parts []geminiPart `json:"parts"`
 }
 
 type geminiPart struct {
-	Text string `json:"text"`
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiFunctionCall struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type geminiToolRequest struct {
+	Contents []geminiContent `json:"contents"`
+	Tools    []geminiTool    `json:"tools"`
 }
 
 type geminiGenConfig struct {
-	Temperature     float64 `json:"temperature"`
-	MaxOutputTokens int     `json:"maxOutputTokens"`
+	Temperature      float64                `json:"temperature"`
+	MaxOutputTokens  int                    `json:"maxOutputTokens"`
+	ResponseMimeType string                 `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]interface{} `json:"responseSchema,omitempty"`
 }
 
 type geminiResponse struct {
 	Candidates []struct {
 		Content struct {
 			Parts []struct {
-				Text string `json:"text"`
+				Text         string              `json:"text"`
+				FunctionCall *geminiFunctionCall `json:"functionCall,omitempty"`
 			} `json:"parts"`
 		} `json:"content"`
 	} `json:"candidates"`
@@ -71,12 +106,19 @@ func NewGemini(cfg *config.Config, verbose bool) *Gemini {
 	}
 
 	return &Gemini{
-		apiKey:  cfg.GeminiAPIKey,
-		models:  models,
-		verbose: verbose,
+		apiKey:    cfg.GeminiAPIKey,
+		models:    models,
+		verbose:   verbose,
+		transport: transport.Default,
 	}
 }
 
+// SetTransport overrides the HTTP transport used for API requests, for
+// offline/deterministic runs against a recorded cassette.
+func (g *Gemini) SetTransport(t transport.Transport) {
+	g.transport = t
+}
+
 // Name returns the provider name
 func (g *Gemini) Name() string {
 	return "gemini"
@@ -87,6 +129,91 @@ func (g *Gemini) IsAvailable() bool {
 	return g.apiKey != ""
 }
 
+// PrimaryModel returns the model StreamCommand uses.
+func (g *Gemini) PrimaryModel() string {
+	return g.models[0]
+}
+
+// SetModel overrides the primary model, keeping the existing fallback
+// chain behind it.
+func (g *Gemini) SetModel(model string) {
+	g.models = append([]string{model}, g.models...)
+}
+
+// StreamCommand streams the model's response using streamGenerateContent
+// with alt=sse, which frames each chunk as a `data: {...}` line.
+func (g *Gemini) StreamCommand(ctx context.Context, prompt string) (<-chan Token, error) {
+	model := g.models[0]
+	reqBody := geminiRequest{
+		Contents: []geminiContent{
+			{
+				Parts: []geminiPart{
+					{Text: prompt},
+				},
+			},
+		},
+		GenerationConfig: geminiGenConfig{
+			Temperature:     0.1,
+			MaxOutputTokens: 500,
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", model, g.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make(chan Token)
+	go func() {
+		defer resp.Body.Close()
+		defer close(tokens)
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+
+			var chunk geminiResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue
+			}
+			if chunk.Error != nil {
+				tokens <- Token{Err: fmt.Errorf("%w: %s", ErrAPIFailure, chunk.Error.Message)}
+				return
+			}
+			if len(chunk.Candidates) == 0 || len(chunk.Candidates[0].Content.Parts) == 0 {
+				continue
+			}
+			if text := chunk.Candidates[0].Content.Parts[0].Text; text != "" {
+				tokens <- Token{Text: text}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			tokens <- Token{Err: err}
+			return
+		}
+		tokens <- Token{Done: true}
+	}()
+
+	return tokens, nil
+}
+
 // GenerateCommand generates a shell command using Gemini
 func (g *Gemini) GenerateCommand(ctx context.Context, prompt string) (*Response, error) {
 	var lastErr error
@@ -118,14 +245,17 @@ func (g *Gemini) GenerateCommand(ctx context.Context, prompt string) (*Response,
 }
 
 func (g *Gemini) callAPI(ctx context.Context, model, prompt string) (*Response, error) {
+	return g.callAPIContents(ctx, model, []geminiContent{
+		{Parts: []geminiPart{{Text: prompt}}},
+	})
+}
+
+// callAPIContents sends a full multi-turn contents list in a single
+// request, used by both callAPI (a single user turn) and
+// GenerateWithHistory.
+func (g *Gemini) callAPIContents(ctx context.Context, model string, contents []geminiContent) (*Response, error) {
 	reqBody := geminiRequest{
-		Contents: []geminiContent{
-			{
-				Parts: []geminiPart{
-					{Text: prompt},
-				},
-			},
-		},
+		Contents: contents,
 		GenerationConfig: geminiGenConfig{
 			Temperature:     0.1,
 			MaxOutputTokens: 500,
@@ -149,8 +279,7 @@ func (g *Gemini) callAPI(ctx context.Context, model, prompt string) (*Response,
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := g.transport.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -197,6 +326,210 @@ func (g *Gemini) callAPI(ctx context.Context, model, prompt string) (*Response,
 	}, nil
 }
 
+// GenerateWithHistory generates a command from a full conversation history
+// instead of a single prompt, so multi-turn refinement carries context
+// from every prior turn rather than just the latest one. Gemini calls the
+// assistant role "model" rather than "assistant".
+func (g *Gemini) GenerateWithHistory(ctx context.Context, history []HistoryMessage) (*Response, error) {
+	contents := make([]geminiContent, len(history))
+	for i, h := range history {
+		role := h.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		contents[i] = geminiContent{Role: role, Parts: []geminiPart{{Text: h.Content}}}
+	}
+
+	var lastErr error
+	for _, model := range g.models {
+		resp, err := g.callAPIContents(ctx, model, contents)
+		if err != nil {
+			if err == ErrModelNotFound {
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrAPIFailure
+}
+
+// GenerateWithTools sends history plus tool definitions using Gemini's
+// native functionDeclarations API, and returns either the model's final
+// text answer or the function calls it wants executed. Tool results in
+// history (Role == "tool") are sent back as "function"-role turns
+// containing a functionResponse part, per Gemini's convention.
+func (g *Gemini) GenerateWithTools(ctx context.Context, history []HistoryMessage, tools []Tool) (*ToolResponse, error) {
+	contents := make([]geminiContent, len(history))
+	for i, h := range history {
+		switch h.Role {
+		case "tool":
+			contents[i] = geminiContent{
+				Role: "function",
+				Parts: []geminiPart{
+					{FunctionResponse: &geminiFunctionResponse{
+						Name:     h.ToolName,
+						Response: map[string]interface{}{"result": h.Content},
+					}},
+				},
+			}
+		case "assistant":
+			role := "model"
+			var parts []geminiPart
+			if h.Content != "" {
+				parts = append(parts, geminiPart{Text: h.Content})
+			}
+			for _, tc := range h.ToolCalls {
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{Name: tc.Name, Args: tc.Arguments}})
+			}
+			contents[i] = geminiContent{Role: role, Parts: parts}
+		default:
+			contents[i] = geminiContent{Role: h.Role, Parts: []geminiPart{{Text: h.Content}}}
+		}
+	}
+
+	declarations := make([]geminiFunctionDeclaration, len(tools))
+	for i, t := range tools {
+		declarations[i] = geminiFunctionDeclaration{Name: t.Name, Description: t.Description, Parameters: t.Parameters}
+	}
+
+	reqBody := geminiToolRequest{
+		Contents: contents,
+		Tools:    []geminiTool{{FunctionDeclarations: declarations}},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	model := g.models[0]
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, g.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result geminiResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAPIFailure, result.Error.Message)
+	}
+	if len(result.Candidates) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	var calls []ToolCall
+	var text strings.Builder
+	for _, part := range result.Candidates[0].Content.Parts {
+		if part.FunctionCall != nil {
+			calls = append(calls, ToolCall{Name: part.FunctionCall.Name, Arguments: part.FunctionCall.Args})
+			continue
+		}
+		text.WriteString(part.Text)
+	}
+
+	if len(calls) > 0 {
+		return &ToolResponse{ToolCalls: calls}, nil
+	}
+	return &ToolResponse{Text: strings.TrimSpace(text.String())}, nil
+}
+
+// GenerateStructuredCommand generates a command using responseSchema so
+// the result is guaranteed to parse as schema.CommandResult.
+func (g *Gemini) GenerateStructuredCommand(ctx context.Context, prompt string) (*Response, error) {
+	model := g.models[0]
+	reqBody := geminiRequest{
+		Contents: []geminiContent{
+			{
+				Parts: []geminiPart{
+					{Text: prompt},
+				},
+			},
+		},
+		GenerationConfig: geminiGenConfig{
+			Temperature:      0.1,
+			MaxOutputTokens:  500,
+			ResponseMimeType: "application/json",
+			ResponseSchema:   schema.JSONSchema,
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, g.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result geminiResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAPIFailure, result.Error.Message)
+	}
+
+	if len(result.Candidates) == 0 ||
+		len(result.Candidates[0].Content.Parts) == 0 ||
+		result.Candidates[0].Content.Parts[0].Text == "" {
+		return nil, ErrEmptyResponse
+	}
+
+	var cr schema.CommandResult
+	if err := json.Unmarshal([]byte(result.Candidates[0].Content.Parts[0].Text), &cr); err != nil {
+		return nil, fmt.Errorf("failed to parse structured response: %w", err)
+	}
+
+	return &Response{
+		Command:      strings.TrimSpace(cr.Command),
+		Model:        model,
+		Provider:     g.Name(),
+		Explanation:  cr.Explanation,
+		Danger:       cr.Danger,
+		RequiresSudo: cr.RequiresSudo,
+	}, nil
+}
+
 // ExplainCommand explains what a shell command does
 func (g *Gemini) ExplainCommand(ctx context.Context, command string) (string, error) {
 	prompt := fmt.Sprintf(`Explain this shell command in simple terms. Break down each flag and option.
@@ -260,8 +593,7 @@ func (g *Gemini) callAPISimple(ctx context.Context, model, prompt string, maxTok
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := g.transport.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/provider/grpcpb"
+)
+
+// GRPCLocal talks to a local inference backend over gRPC instead of HTTP,
+// so a model server (llama.cpp, Ollama, or a custom process implementing
+// provider.v1.LocalProvider, see proto/provider/v1/provider.proto) can
+// stay resident across `x` invocations instead of paying a cold-start
+// cost on every call. The connection is dialed lazily on first use and
+// reused for the lifetime of the process.
+type GRPCLocal struct {
+	addr    string
+	verbose bool
+
+	mu     sync.Mutex
+	conn   *grpc.ClientConn
+	client grpcpb.LocalProviderClient
+}
+
+// NewGRPCLocal creates a new gRPC-backed local provider pointed at
+// cfg.GRPCProviderAddr (set via config or the GRPC_PROVIDER_ADDR env var).
+func NewGRPCLocal(cfg *config.Config, verbose bool) *GRPCLocal {
+	return &GRPCLocal{
+		addr:    cfg.GRPCProviderAddr,
+		verbose: verbose,
+	}
+}
+
+// Name returns the provider identifier.
+func (g *GRPCLocal) Name() string {
+	return "grpc-local"
+}
+
+// IsAvailable reports whether a backend address has been configured. It
+// does not dial the connection - a misbehaving or unreachable backend
+// surfaces its error on the first actual call instead.
+func (g *GRPCLocal) IsAvailable() bool {
+	return g.addr != ""
+}
+
+// dial lazily connects to addr and caches the connection, so repeated
+// calls (and the warm model behind them) are reused rather than
+// reconnecting per invocation.
+func (g *GRPCLocal) dial() (grpcpb.LocalProviderClient, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.client != nil {
+		return g.client, nil
+	}
+
+	conn, err := grpc.NewClient(g.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("dialing grpc provider at %s: %w", g.addr, err)
+	}
+
+	g.conn = conn
+	g.client = grpcpb.NewLocalProviderClient(conn)
+	return g.client, nil
+}
+
+// GenerateCommand sends a prompt and buffers the streamed tokens into a
+// single command string.
+func (g *GRPCLocal) GenerateCommand(ctx context.Context, prompt string) (*Response, error) {
+	tokens, err := g.StreamCommand(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var command string
+	for tok := range tokens {
+		if tok.Err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAPIFailure, tok.Err)
+		}
+		command += tok.Text
+	}
+
+	return &Response{
+		Command:  command,
+		Model:    g.addr,
+		Provider: g.Name(),
+	}, nil
+}
+
+// StreamCommand streams the backend's response to prompt token by token.
+func (g *GRPCLocal) StreamCommand(ctx context.Context, prompt string) (<-chan Token, error) {
+	client, err := g.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := client.Generate(ctx, &grpcpb.GenerateRequest{Prompt: prompt})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAPIFailure, err)
+	}
+
+	out := make(chan Token)
+	go func() {
+		defer close(out)
+		for {
+			tok, err := stream.Recv()
+			if err == io.EOF {
+				out <- Token{Done: true}
+				return
+			}
+			if err != nil {
+				out <- Token{Err: err}
+				return
+			}
+			out <- Token{Text: tok.Text, Done: tok.Done}
+			if tok.Done {
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// ExplainCommand asks the backend what command does.
+func (g *GRPCLocal) ExplainCommand(ctx context.Context, command string) (string, error) {
+	client, err := g.dial()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Explain(ctx, &grpcpb.ExplainRequest{Command: command})
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAPIFailure, err)
+	}
+	if resp.Explanation == "" {
+		return "", ErrEmptyResponse
+	}
+
+	return resp.Explanation, nil
+}
+
+// RefineCommand asks the backend to adjust command given refinement.
+func (g *GRPCLocal) RefineCommand(ctx context.Context, command, refinement string) (*Response, error) {
+	client, err := g.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Refine(ctx, &grpcpb.RefineRequest{Command: command, Refinement: refinement})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAPIFailure, err)
+	}
+	if resp.Command == "" {
+		return nil, ErrEmptyResponse
+	}
+
+	return &Response{
+		Command:  resp.Command,
+		Model:    g.addr,
+		Provider: g.Name(),
+	}, nil
+}
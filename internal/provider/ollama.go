@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -11,13 +12,16 @@ import (
 	"strings"
 
 	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/provider/transport"
+	"github.com/REDFOX1899/ask-sh/internal/schema"
 )
 
 // Ollama provider implementation (local LLM)
 type Ollama struct {
-	model   string
-	host    string
-	verbose bool
+	model     string
+	host      string
+	verbose   bool
+	transport transport.Transport
 }
 
 // Ollama API request/response types
@@ -25,29 +29,65 @@ type ollamaRequest struct {
 	Model    string          `json:"model"`
 	Messages []ollamaMessage `json:"messages"`
 	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"`
 }
 
 type ollamaMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
 }
 
 type ollamaResponse struct {
 	Message struct {
-		Content string `json:"content"`
+		Content   string           `json:"content"`
+		ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
 	} `json:"message"`
+	Done  bool   `json:"done"`
 	Error string `json:"error,omitempty"`
 }
 
+type ollamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaTool struct {
+	Type     string             `json:"type"`
+	Function ollamaToolFunction `json:"function"`
+}
+
+type ollamaToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type ollamaToolRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
 // NewOllama creates a new Ollama provider
 func NewOllama(cfg *config.Config, verbose bool) *Ollama {
 	return &Ollama{
-		model:   cfg.OllamaModel,
-		host:    cfg.OllamaHost,
-		verbose: verbose,
+		model:     cfg.OllamaModel,
+		host:      cfg.OllamaHost,
+		verbose:   verbose,
+		transport: transport.Default,
 	}
 }
 
+// SetTransport overrides the HTTP transport used for API requests, for
+// offline/deterministic runs against a recorded cassette.
+func (o *Ollama) SetTransport(t transport.Transport) {
+	o.transport = t
+}
+
 // Name returns the provider name
 func (o *Ollama) Name() string {
 	return "ollama"
@@ -58,18 +98,191 @@ func (o *Ollama) IsAvailable() bool {
 	return o.model != ""
 }
 
+// PrimaryModel returns the model StreamCommand uses.
+func (o *Ollama) PrimaryModel() string {
+	return o.model
+}
+
+// SetModel overrides the model this provider talks to.
+func (o *Ollama) SetModel(model string) {
+	o.model = model
+}
+
+// StreamCommand streams the model's response as newline-delimited JSON.
+func (o *Ollama) StreamCommand(ctx context.Context, prompt string) (<-chan Token, error) {
+	reqBody := ollamaRequest{
+		Model: o.model,
+		Messages: []ollamaMessage{
+			{Role: "user", Content: prompt},
+		},
+		Stream: true,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/chat", o.host)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.transport.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama at %s: %w", o.host, err)
+	}
+
+	tokens := make(chan Token)
+	go func() {
+		defer resp.Body.Close()
+		defer close(tokens)
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+
+			var chunk ollamaResponse
+			if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+				continue
+			}
+			if chunk.Error != "" {
+				tokens <- Token{Err: fmt.Errorf("%w: %s", ErrAPIFailure, chunk.Error)}
+				return
+			}
+			if chunk.Message.Content != "" {
+				tokens <- Token{Text: chunk.Message.Content}
+			}
+			if chunk.Done {
+				tokens <- Token{Done: true}
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			tokens <- Token{Err: err}
+		}
+	}()
+
+	return tokens, nil
+}
+
 // GenerateCommand generates a shell command using Ollama
 func (o *Ollama) GenerateCommand(ctx context.Context, prompt string) (*Response, error) {
+	return o.generateMessages(ctx, []ollamaMessage{
+		{Role: "user", Content: prompt},
+	})
+}
+
+// GenerateWithHistory generates a command from a full conversation history
+// instead of a single prompt, so multi-turn refinement carries context
+// from every prior turn rather than just the latest one.
+func (o *Ollama) GenerateWithHistory(ctx context.Context, history []HistoryMessage) (*Response, error) {
+	messages := make([]ollamaMessage, len(history))
+	for i, h := range history {
+		messages[i] = ollamaMessage{Role: h.Role, Content: h.Content}
+	}
+	return o.generateMessages(ctx, messages)
+}
+
+// GenerateWithTools sends history plus tool definitions using Ollama's
+// OpenAI-compatible tools API, and returns either the model's final text
+// answer or the tool calls it wants executed. Tool results in history
+// (Role == "tool") are sent back as "tool"-role messages carrying only the
+// result text - no tool name or call id - since Ollama's tool_calls don't
+// carry an id.
+func (o *Ollama) GenerateWithTools(ctx context.Context, history []HistoryMessage, tools []Tool) (*ToolResponse, error) {
+	messages := make([]ollamaMessage, len(history))
+	for i, h := range history {
+		msg := ollamaMessage{Role: h.Role, Content: h.Content}
+		for _, tc := range h.ToolCalls {
+			var call ollamaToolCall
+			call.Function.Name = tc.Name
+			call.Function.Arguments = tc.Arguments
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		messages[i] = msg
+	}
+
+	ollamaTools := make([]ollamaTool, len(tools))
+	for i, t := range tools {
+		ollamaTools[i] = ollamaTool{
+			Type: "function",
+			Function: ollamaToolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		}
+	}
+
+	reqBody := ollamaToolRequest{
+		Model:    o.model,
+		Messages: messages,
+		Tools:    ollamaTools,
+		Stream:   false,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/chat", o.host)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.transport.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama at %s: %w", o.host, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ollamaResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != "" {
+		return nil, fmt.Errorf("%w: %s", ErrAPIFailure, result.Error)
+	}
+
+	if len(result.Message.ToolCalls) == 0 {
+		return &ToolResponse{Text: strings.TrimSpace(result.Message.Content)}, nil
+	}
+
+	calls := make([]ToolCall, len(result.Message.ToolCalls))
+	for i, tc := range result.Message.ToolCalls {
+		calls[i] = ToolCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments}
+	}
+	return &ToolResponse{ToolCalls: calls}, nil
+}
+
+// generateMessages sends a full message history in a single turn, used by
+// both GenerateCommand (a single user message) and GenerateWithHistory.
+func (o *Ollama) generateMessages(ctx context.Context, messages []ollamaMessage) (*Response, error) {
 	if o.verbose {
 		fmt.Fprintf(os.Stderr, "DEBUG: Using Ollama model: %s at %s\n", o.model, o.host)
 	}
 
 	reqBody := ollamaRequest{
-		Model: o.model,
-		Messages: []ollamaMessage{
-			{Role: "user", Content: prompt},
-		},
-		Stream: false,
+		Model:    o.model,
+		Messages: messages,
+		Stream:   false,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -89,8 +302,7 @@ func (o *Ollama) GenerateCommand(ctx context.Context, prompt string) (*Response,
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := o.transport.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ollama at %s: %w", o.host, err)
 	}
@@ -132,6 +344,76 @@ func (o *Ollama) GenerateCommand(ctx context.Context, prompt string) (*Response,
 	}, nil
 }
 
+// GenerateStructuredCommand generates a command using Ollama's "format":
+// "json" option, describing the desired shape in the prompt since Ollama
+// only guarantees valid JSON, not a specific schema.
+func (o *Ollama) GenerateStructuredCommand(ctx context.Context, prompt string) (*Response, error) {
+	structuredPrompt := fmt.Sprintf(`%s
+
+Respond with a single JSON object matching this shape, and nothing else:
+{"command": "<shell command>", "explanation": "<short explanation>", "danger": "low|medium|high", "requires_sudo": <true|false>}`, prompt)
+
+	reqBody := ollamaRequest{
+		Model: o.model,
+		Messages: []ollamaMessage{
+			{Role: "user", Content: structuredPrompt},
+		},
+		Stream: false,
+		Format: "json",
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/chat", o.host)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.transport.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama at %s: %w", o.host, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ollamaResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != "" {
+		return nil, fmt.Errorf("%w: %s", ErrAPIFailure, result.Error)
+	}
+
+	if result.Message.Content == "" {
+		return nil, ErrEmptyResponse
+	}
+
+	var cr schema.CommandResult
+	if err := json.Unmarshal([]byte(result.Message.Content), &cr); err != nil {
+		return nil, fmt.Errorf("failed to parse structured response: %w", err)
+	}
+
+	return &Response{
+		Command:      strings.TrimSpace(cr.Command),
+		Model:        o.model,
+		Provider:     o.Name(),
+		Explanation:  cr.Explanation,
+		Danger:       cr.Danger,
+		RequiresSudo: cr.RequiresSudo,
+	}, nil
+}
+
 // ExplainCommand explains what a shell command does
 func (o *Ollama) ExplainCommand(ctx context.Context, command string) (string, error) {
 	prompt := fmt.Sprintf(`Explain this shell command in simple terms. Break down each flag and option.
@@ -189,8 +471,7 @@ func (o *Ollama) callAPISimple(ctx context.Context, prompt string) (string, erro
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := o.transport.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to connect to Ollama at %s: %w", o.host, err)
 	}
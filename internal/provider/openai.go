@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -11,26 +12,45 @@ import (
 	"strings"
 
 	"github.com/REDFOX1899/ask-sh/internal/config"
+	"github.com/REDFOX1899/ask-sh/internal/provider/transport"
+	"github.com/REDFOX1899/ask-sh/internal/schema"
 )
 
 // OpenAI provider implementation
 type OpenAI struct {
-	apiKey  string
-	models  []string
-	verbose bool
+	apiKey    string
+	models    []string
+	verbose   bool
+	transport transport.Transport
 }
 
 // OpenAI API request/response types
 type openAIRequest struct {
-	Model       string           `json:"model"`
-	Messages    []openAIMessage  `json:"messages"`
-	Temperature float64          `json:"temperature"`
-	MaxTokens   int              `json:"max_tokens"`
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature float64         `json:"temperature"`
+	MaxTokens   int             `json:"max_tokens"`
+	Stream      bool            `json:"stream,omitempty"`
+}
+
+// openAIStreamChunk is a single SSE `data: {...}` frame from the streaming
+// chat.completions endpoint.
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Error *openAIError `json:"error,omitempty"`
 }
 
 type openAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	Name       string           `json:"name,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
 }
 
 type openAIResponse struct {
@@ -48,6 +68,62 @@ type openAIError struct {
 	Code    string `json:"code"`
 }
 
+type openAIToolRequest struct {
+	Model      string          `json:"model"`
+	Messages   []openAIMessage `json:"messages"`
+	Tools      []openAITool    `json:"tools,omitempty"`
+	ToolChoice string          `json:"tool_choice,omitempty"`
+}
+
+type openAITool struct {
+	Type     string             `json:"type"`
+	Function openAIToolFunction `json:"function"`
+}
+
+type openAIToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIToolResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string           `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Error *openAIError `json:"error,omitempty"`
+}
+
+type openAIStructuredRequest struct {
+	Model          string               `json:"model"`
+	Messages       []openAIMessage      `json:"messages"`
+	Temperature    float64              `json:"temperature"`
+	MaxTokens      int                  `json:"max_tokens"`
+	ResponseFormat openAIResponseFormat `json:"response_format"`
+}
+
+type openAIResponseFormat struct {
+	Type       string           `json:"type"`
+	JSONSchema openAIJSONSchema `json:"json_schema"`
+}
+
+type openAIJSONSchema struct {
+	Name   string                 `json:"name"`
+	Strict bool                   `json:"strict"`
+	Schema map[string]interface{} `json:"schema"`
+}
+
 // NewOpenAI creates a new OpenAI provider
 func NewOpenAI(cfg *config.Config, verbose bool) *OpenAI {
 	models := []string{cfg.OpenAIModel}
@@ -60,12 +136,19 @@ func NewOpenAI(cfg *config.Config, verbose bool) *OpenAI {
 	}
 
 	return &OpenAI{
-		apiKey:  cfg.OpenAIAPIKey,
-		models:  models,
-		verbose: verbose,
+		apiKey:    cfg.OpenAIAPIKey,
+		models:    models,
+		verbose:   verbose,
+		transport: transport.Default,
 	}
 }
 
+// SetTransport overrides the HTTP transport used for API requests, for
+// offline/deterministic runs against a recorded cassette.
+func (o *OpenAI) SetTransport(t transport.Transport) {
+	o.transport = t
+}
+
 // Name returns the provider name
 func (o *OpenAI) Name() string {
 	return "openai"
@@ -76,6 +159,92 @@ func (o *OpenAI) IsAvailable() bool {
 	return o.apiKey != ""
 }
 
+// PrimaryModel returns the model StreamCommand uses.
+func (o *OpenAI) PrimaryModel() string {
+	return o.models[0]
+}
+
+// SetModel overrides the primary model, keeping the existing fallback
+// chain behind it.
+func (o *OpenAI) SetModel(model string) {
+	o.models = append([]string{model}, o.models...)
+}
+
+// StreamCommand streams the model's response over SSE.
+func (o *OpenAI) StreamCommand(ctx context.Context, prompt string) (<-chan Token, error) {
+	reqBody := openAIRequest{
+		Model: o.models[0],
+		Messages: []openAIMessage{
+			{Role: "user", Content: prompt},
+		},
+		Temperature: 0.1,
+		MaxTokens:   500,
+		Stream:      true,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := o.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make(chan Token)
+	go func() {
+		defer resp.Body.Close()
+		defer close(tokens)
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "[DONE]" {
+				tokens <- Token{Done: true}
+				return
+			}
+
+			var chunk openAIStreamChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue
+			}
+			if chunk.Error != nil {
+				tokens <- Token{Err: fmt.Errorf("%w: %s", ErrAPIFailure, chunk.Error.Message)}
+				return
+			}
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+			if text := chunk.Choices[0].Delta.Content; text != "" {
+				tokens <- Token{Text: text}
+			}
+			if chunk.Choices[0].FinishReason != "" {
+				tokens <- Token{Done: true}
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			tokens <- Token{Err: err}
+		}
+	}()
+
+	return tokens, nil
+}
+
 // GenerateCommand generates a shell command using OpenAI
 func (o *OpenAI) GenerateCommand(ctx context.Context, prompt string) (*Response, error) {
 	var lastErr error
@@ -107,11 +276,17 @@ func (o *OpenAI) GenerateCommand(ctx context.Context, prompt string) (*Response,
 }
 
 func (o *OpenAI) callAPI(ctx context.Context, model, prompt string) (*Response, error) {
+	return o.callAPIMessages(ctx, model, []openAIMessage{
+		{Role: "user", Content: prompt},
+	})
+}
+
+// callAPIMessages sends a full message history in a single turn, used by
+// both callAPI (a single user message) and GenerateWithHistory.
+func (o *OpenAI) callAPIMessages(ctx context.Context, model string, messages []openAIMessage) (*Response, error) {
 	reqBody := openAIRequest{
-		Model: model,
-		Messages: []openAIMessage{
-			{Role: "user", Content: prompt},
-		},
+		Model:       model,
+		Messages:    messages,
 		Temperature: 0.1,
 		MaxTokens:   500,
 	}
@@ -133,8 +308,7 @@ func (o *OpenAI) callAPI(ctx context.Context, model, prompt string) (*Response,
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+o.apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := o.transport.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -179,6 +353,196 @@ func (o *OpenAI) callAPI(ctx context.Context, model, prompt string) (*Response,
 	}, nil
 }
 
+// GenerateWithHistory generates a command from a full conversation history
+// instead of a single prompt, so multi-turn refinement carries context
+// from every prior turn rather than just the latest one.
+func (o *OpenAI) GenerateWithHistory(ctx context.Context, history []HistoryMessage) (*Response, error) {
+	messages := make([]openAIMessage, len(history))
+	for i, h := range history {
+		messages[i] = openAIMessage{Role: h.Role, Content: h.Content}
+	}
+
+	var lastErr error
+	for _, model := range o.models {
+		resp, err := o.callAPIMessages(ctx, model, messages)
+		if err != nil {
+			if err == ErrModelNotFound {
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrAPIFailure
+}
+
+// GenerateWithTools sends history plus tool definitions using OpenAI's
+// native function-calling API, and returns either the model's final text
+// answer or the tool calls it wants executed.
+func (o *OpenAI) GenerateWithTools(ctx context.Context, history []HistoryMessage, tools []Tool) (*ToolResponse, error) {
+	messages := make([]openAIMessage, len(history))
+	for i, h := range history {
+		msg := openAIMessage{Role: h.Role, Content: h.Content, Name: h.ToolName, ToolCallID: h.ToolCallID}
+		for _, tc := range h.ToolCalls {
+			args, _ := json.Marshal(tc.Arguments)
+			call := openAIToolCall{ID: tc.ID, Type: "function"}
+			call.Function.Name = tc.Name
+			call.Function.Arguments = string(args)
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		messages[i] = msg
+	}
+
+	openAITools := make([]openAITool, len(tools))
+	for i, t := range tools {
+		openAITools[i] = openAITool{
+			Type: "function",
+			Function: openAIToolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		}
+	}
+
+	reqBody := openAIToolRequest{
+		Model:      o.models[0],
+		Messages:   messages,
+		Tools:      openAITools,
+		ToolChoice: "auto",
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+
+	resp, err := o.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result openAIToolResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAPIFailure, result.Error.Message)
+	}
+	if len(result.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	message := result.Choices[0].Message
+	if len(message.ToolCalls) == 0 {
+		return &ToolResponse{Text: strings.TrimSpace(message.Content)}, nil
+	}
+
+	calls := make([]ToolCall, len(message.ToolCalls))
+	for i, tc := range message.ToolCalls {
+		var args map[string]interface{}
+		if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+			return nil, fmt.Errorf("failed to parse tool call arguments: %w", err)
+		}
+		calls[i] = ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: args}
+	}
+
+	return &ToolResponse{ToolCalls: calls}, nil
+}
+
+// GenerateStructuredCommand generates a command using response_format:
+// json_schema so the result is guaranteed to parse as schema.CommandResult.
+func (o *OpenAI) GenerateStructuredCommand(ctx context.Context, prompt string) (*Response, error) {
+	model := o.models[0]
+	reqBody := openAIStructuredRequest{
+		Model: model,
+		Messages: []openAIMessage{
+			{Role: "user", Content: prompt},
+		},
+		Temperature: 0.1,
+		MaxTokens:   500,
+		ResponseFormat: openAIResponseFormat{
+			Type: "json_schema",
+			JSONSchema: openAIJSONSchema{
+				Name:   schema.Name,
+				Strict: true,
+				Schema: schema.JSONSchema,
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+
+	resp, err := o.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result openAIResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAPIFailure, result.Error.Message)
+	}
+
+	if len(result.Choices) == 0 || result.Choices[0].Message.Content == "" {
+		return nil, ErrEmptyResponse
+	}
+
+	var cr schema.CommandResult
+	if err := json.Unmarshal([]byte(result.Choices[0].Message.Content), &cr); err != nil {
+		return nil, fmt.Errorf("failed to parse structured response: %w", err)
+	}
+
+	return &Response{
+		Command:      strings.TrimSpace(cr.Command),
+		Model:        model,
+		Provider:     o.Name(),
+		Explanation:  cr.Explanation,
+		Danger:       cr.Danger,
+		RequiresSudo: cr.RequiresSudo,
+	}, nil
+}
+
 // ExplainCommand explains what a shell command does
 func (o *OpenAI) ExplainCommand(ctx context.Context, command string) (string, error) {
 	prompt := fmt.Sprintf(`Explain this shell command in simple terms. Break down each flag and option.
@@ -237,8 +601,7 @@ func (o *OpenAI) callAPISimple(ctx context.Context, model, prompt string, maxTok
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+o.apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := o.transport.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -3,11 +3,13 @@ package provider
 import (
 	"context"
 	"errors"
+
+	"github.com/REDFOX1899/ask-sh/internal/provider/transport"
 )
 
 // Common errors
 var (
-	ErrNoProvider    = errors.New("no API provider configured. Set one of: OPENAI_API_KEY, ANTHROPIC_API_KEY, GEMINI_API_KEY, or OLLAMA_MODEL")
+	ErrNoProvider    = errors.New("no API provider configured. Set one of: OPENAI_API_KEY, ANTHROPIC_API_KEY, GEMINI_API_KEY, OLLAMA_MODEL, or GRPC_PROVIDER_ADDR")
 	ErrModelNotFound = errors.New("model not found")
 	ErrAPIFailure    = errors.New("API request failed")
 	ErrEmptyResponse = errors.New("empty response from API")
@@ -36,4 +38,112 @@ type Response struct {
 	Command  string // The generated shell command
 	Model    string // Which model was used
 	Provider string // Which provider was used
+
+	// The following are only populated by StructuredProvider.GenerateStructuredCommand.
+	Explanation  string // Why the model chose this command
+	Danger       string // Model's own risk assessment: "low", "medium", or "high"
+	RequiresSudo bool   // Whether the model believes the command needs sudo
+}
+
+// HistoryMessage is one turn of conversation passed to GenerateWithHistory.
+// Role is "user" or "assistant".
+type HistoryMessage struct {
+	Role    string
+	Content string
+
+	// ToolName and ToolCallID are only set when Role == "tool": they carry
+	// a tool's result back to the model, answering the ToolCall it made.
+	ToolName   string
+	ToolCallID string
+
+	// ToolCalls is only set when Role == "assistant" and the message is a
+	// prior turn in which the model asked to run tools rather than answer.
+	ToolCalls []ToolCall
+}
+
+// HistoryProvider is an optional capability implemented by providers that
+// can generate from a full message history instead of a single prompt, so
+// refinement sends the full conversation context rather than just the
+// latest (command, refinement) pair.
+type HistoryProvider interface {
+	GenerateWithHistory(ctx context.Context, history []HistoryMessage) (*Response, error)
+}
+
+// ModelSetter is an optional capability implemented by providers whose
+// model id can be overridden after construction, used when a --model
+// alias resolves to a specific model on an already-registered provider.
+type ModelSetter interface {
+	SetModel(model string)
+}
+
+// StructuredProvider is an optional capability implemented by providers
+// that can constrain the model to return schema.CommandResult instead of
+// a bare command string, so parsing doesn't depend on the model obeying
+// prompt instructions.
+type StructuredProvider interface {
+	// GenerateStructuredCommand behaves like GenerateCommand but requests
+	// structured output and populates Explanation, Danger, and
+	// RequiresSudo on the returned Response.
+	GenerateStructuredCommand(ctx context.Context, prompt string) (*Response, error)
+}
+
+// Token is one piece of incrementally streamed output.
+type Token struct {
+	Text string // incremental text delta, may be empty on the final token
+	Done bool   // true once the stream is complete
+	Err  error  // set if the stream failed; the channel is closed afterwards
+}
+
+// StreamingProvider is an optional capability implemented by providers that
+// can emit output incrementally instead of waiting for the full response.
+// Callers should type-assert a Provider to StreamingProvider and fall back
+// to the buffered GenerateCommand/ExplainCommand methods when a provider
+// doesn't implement it.
+type StreamingProvider interface {
+	// StreamCommand streams the model's raw text response to prompt using
+	// the provider's primary model. The returned channel is closed after
+	// the final Token (Done == true) or after a Token carrying Err.
+	StreamCommand(ctx context.Context, prompt string) (<-chan Token, error)
+}
+
+// Tool describes a single callable function offered to the model, translated
+// into each provider's native function/tool-calling schema. Parameters is a
+// JSON Schema object describing the function's arguments, in the same shape
+// as schema.JSONSchema.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// ToolCall is one invocation the model asked the caller to make.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments map[string]interface{}
+}
+
+// ToolResponse is the result of one turn of tool-calling generation: a
+// model either answers with final Text, or asks the caller to run one or
+// more ToolCalls and feed the results back as "tool"-role HistoryMessages.
+type ToolResponse struct {
+	Text      string
+	ToolCalls []ToolCall
+}
+
+// TransportSetter is an optional capability implemented by providers whose
+// HTTP transport can be swapped after construction, used to point a
+// provider at a transport.RecordingTransport or transport.ReplayTransport
+// instead of the real network for offline/deterministic runs.
+type TransportSetter interface {
+	SetTransport(t transport.Transport)
+}
+
+// ToolProvider is an optional capability implemented by providers that
+// support native function/tool calling, so agent mode can hand the model
+// structured tool invocations instead of parsing them out of free text.
+type ToolProvider interface {
+	// GenerateWithTools sends history plus the available tools and returns
+	// either a final answer or the tool calls the model wants executed.
+	GenerateWithTools(ctx context.Context, history []HistoryMessage, tools []Tool) (*ToolResponse, error)
 }
@@ -1,6 +1,8 @@
 package provider
 
 import (
+	"fmt"
+
 	"github.com/REDFOX1899/ask-sh/internal/config"
 )
 
@@ -22,6 +24,7 @@ func NewRegistry(cfg *config.Config, verbose bool) *Registry {
 		NewAnthropic(cfg, verbose),
 		NewGemini(cfg, verbose),
 		NewOllama(cfg, verbose),
+		NewGRPCLocal(cfg, verbose),
 	}
 
 	return r
@@ -54,3 +57,26 @@ func (r *Registry) Get(name string) (Provider, error) {
 func (r *Registry) List() []Provider {
 	return r.providers
 }
+
+// ResolveModel looks up alias in the configured model aliases
+// (~/.x/models/<alias>.yaml) and returns the backing provider, with its
+// model id overridden to the alias's model if it implements ModelSetter.
+func (r *Registry) ResolveModel(alias string) (Provider, error) {
+	mc, ok := r.cfg.Models[alias]
+	if !ok {
+		return nil, fmt.Errorf("unknown model alias %q", alias)
+	}
+
+	p, err := r.Get(mc.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if mc.Model != "" {
+		if ms, ok := p.(ModelSetter); ok {
+			ms.SetModel(mc.Model)
+		}
+	}
+
+	return p, nil
+}
@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// Entry is one recorded request/response pair in a cassette file, one JSON
+// object per line so a cassette can be appended to incrementally and
+// inspected with standard line tools. The query string is deliberately
+// dropped from URL before it's ever recorded - Gemini passes its API key
+// as a query parameter, and a cassette is meant to be safe to check in and
+// replay without any credentials.
+type Entry struct {
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	ReqBody  string `json:"req_body,omitempty"`
+	Status   int    `json:"status"`
+	RespBody string `json:"resp_body"`
+}
+
+// cassetteKey identifies a request for matching against a cassette,
+// ignoring the query string for the same reason Entry does.
+func cassetteKey(method string, u *url.URL) string {
+	return method + " " + u.Scheme + "://" + u.Host + u.Path
+}
+
+// RecordingTransport wraps an underlying Transport and appends each
+// request/response pair it sees to a JSONL cassette file at Path, so a
+// live session can be captured and replayed later via ReplayTransport.
+type RecordingTransport struct {
+	underlying Transport
+	path       string
+
+	mu sync.Mutex
+}
+
+// NewRecordingTransport returns a RecordingTransport that forwards every
+// request to underlying and appends the resulting pair to the cassette
+// file at path, creating it if necessary.
+func NewRecordingTransport(underlying Transport, path string) *RecordingTransport {
+	return &RecordingTransport{underlying: underlying, path: path}
+}
+
+// Do sends req through the underlying transport and records the exchange
+// before returning the response, whose body is replaced with a fresh
+// reader since recording already consumed the original.
+func (t *RecordingTransport) Do(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := t.underlying.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err := t.append(Entry{
+		Method:   req.Method,
+		URL:      req.URL.Scheme + "://" + req.URL.Host + req.URL.Path,
+		ReqBody:  string(reqBody),
+		Status:   resp.StatusCode,
+		RespBody: string(respBody),
+	}); err != nil {
+		return nil, fmt.Errorf("recording cassette entry: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (t *RecordingTransport) append(entry Entry) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// ReplayTransport serves requests from a cassette recorded by
+// RecordingTransport instead of hitting the network, matching each
+// request against the next unconsumed entry for its method+URL so
+// interleaved calls to different endpoints (e.g. a model fallback chain)
+// still line up. It's safe for concurrent use.
+type ReplayTransport struct {
+	mu      sync.Mutex
+	entries map[string][]Entry
+}
+
+// LoadReplayTransport reads every entry from the cassette at path into
+// memory, ready to serve Do calls without touching the network.
+func LoadReplayTransport(path string) (*ReplayTransport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string][]Entry)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+		}
+		key := e.Method + " " + e.URL
+		entries[key] = append(entries[key], e)
+	}
+
+	return &ReplayTransport{entries: entries}, nil
+}
+
+// Do returns the next cassette entry recorded for req's method and URL
+// (query string ignored), without making any network call.
+func (t *ReplayTransport) Do(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := cassetteKey(req.Method, req.URL)
+	queue := t.entries[key]
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("no cassette entry left for %s", key)
+	}
+	entry := queue[0]
+	t.entries[key] = queue[1:]
+
+	return &http.Response{
+		StatusCode: entry.Status,
+		Status:     http.StatusText(entry.Status),
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewReader([]byte(entry.RespBody))),
+		Request:    req,
+	}, nil
+}
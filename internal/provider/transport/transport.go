@@ -0,0 +1,18 @@
+// Package transport abstracts the one thing every provider's HTTP code has
+// in common - sending a request and getting a response back - behind a
+// small interface, so that step can be swapped for a recording or
+// replaying implementation without any provider knowing the difference.
+package transport
+
+import "net/http"
+
+// Transport sends a single HTTP request and returns its response. It's the
+// same contract as http.Client.Do, so a *http.Client already satisfies it
+// and providers don't need any adapter to use the real network.
+type Transport interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Default is the Transport every provider uses unless overridden: a plain
+// *http.Client with no special behavior.
+var Default Transport = &http.Client{}
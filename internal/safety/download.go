@@ -0,0 +1,88 @@
+package safety
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+
+	"github.com/REDFOX1899/ask-sh/internal/safety/parse"
+)
+
+// urlPattern matches the first http(s) URL in a command's argv, used to
+// find the payload a NETWORK-MUTATING curl/wget pipe-into-shell command
+// would otherwise fetch and execute blind.
+var urlPattern = regexp.MustCompile(`^https?://\S+$`)
+
+// DownloadReview is the result of fetching a network-mutating command's
+// payload instead of piping it straight into a shell: where it was saved
+// and what the safety analyzer makes of its contents.
+type DownloadReview struct {
+	URL        string
+	Path       string
+	Assessment RiskAssessment
+}
+
+// ReviewDownload extracts the first URL from a curl/wget pipe-into-shell
+// command, fetches it into a tempfile, and re-analyzes the downloaded
+// content with rs instead of the pipeline that would have run it directly.
+// The tempfile is left on disk at the returned Path for the user to
+// inspect; it's the caller's responsibility to remove it once reviewed.
+func (rs *RuleSet) ReviewDownload(command string) (*DownloadReview, error) {
+	url, err := extractURL(command)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", url, err)
+	}
+
+	f, err := os.CreateTemp("", "x-download-review-*.sh")
+	if err != nil {
+		return nil, fmt.Errorf("creating tempfile: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(body); err != nil {
+		return nil, fmt.Errorf("writing %s: %w", f.Name(), err)
+	}
+
+	return &DownloadReview{
+		URL:        url,
+		Path:       f.Name(),
+		Assessment: rs.Analyze(string(body)),
+	}, nil
+}
+
+// ReviewDownload runs ReviewDownload against the Default RuleSet.
+func ReviewDownload(command string) (*DownloadReview, error) {
+	return Default.ReviewDownload(command)
+}
+
+// extractURL returns the first http(s) URL among command's parsed simple
+// commands' arguments.
+func extractURL(command string) (string, error) {
+	commands, err := parse.Parse(command)
+	if err != nil {
+		return "", fmt.Errorf("parsing %q: %w", command, err)
+	}
+
+	for _, c := range commands {
+		for _, arg := range c.Argv {
+			if urlPattern.MatchString(arg) {
+				return arg, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no URL found in %q", command)
+}
@@ -0,0 +1,229 @@
+// Package parse tokenizes shell command lines with a real POSIX parser so
+// safety rules can be evaluated against structured argv rather than a raw,
+// lowercased string. That distinction matters: `echo "rm -rf /"` should not
+// match a rule looking for the rm command, `rm -rf -- ./build` should not be
+// mistaken for `rm -rf /`, and `/Users/Alice` should not be lowercased into
+// something it isn't.
+package parse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Command is one simple command extracted from a shell command line: its
+// argv (after stripping sudo/env prefixes), the files it redirects to, and
+// its position within any pipeline it's part of. Start and End are byte
+// offsets into the original source, so callers can highlight exactly the
+// token that tripped a rule.
+type Command struct {
+	Argv        []string
+	Redirects   []Redirect
+	PipelinePos int // 0 for a standalone command or the first stage of a pipeline
+	PipelineLen int // 1 for a standalone command, >1 inside a pipeline
+	Start, End  int
+}
+
+// Redirect is one output/input redirection attached to a command, e.g. the
+// `> /etc/passwd` in `echo root::0:0::/:/bin/sh > /etc/passwd`. Op is the
+// shell operator as written (">", ">>", "<", ...), which callers like
+// dryrun need to tell an overwrite from an append from a read.
+type Redirect struct {
+	Op     string
+	Target string
+}
+
+// Name returns the command name (argv[0]), or "" for a bare assignment like
+// `RM=/tmp` that has no command to run.
+func (c Command) Name() string {
+	if len(c.Argv) == 0 {
+		return ""
+	}
+	return c.Argv[0]
+}
+
+// HasFlag reports whether flag (e.g. "-r" or "--recursive") was passed,
+// either as its own argument or packed into a combined short-flag cluster
+// such as "-rf" for "-r".
+func (c Command) HasFlag(flag string) bool {
+	if len(c.Argv) < 2 {
+		return false
+	}
+	if !strings.HasPrefix(flag, "-") || strings.HasPrefix(flag, "--") {
+		for _, a := range c.Argv[1:] {
+			if a == flag {
+				return true
+			}
+		}
+		return false
+	}
+	letter := strings.TrimPrefix(flag, "-")
+	for _, a := range c.Argv[1:] {
+		if strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.Contains(a[1:], letter) {
+			return true
+		}
+	}
+	return false
+}
+
+// ArgMatches reports whether any argument after the command name
+// (argv[1:], flags included) matches the given regexp pattern.
+func (c Command) ArgMatches(pattern string) (bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	for _, a := range c.Argv[1:] {
+		if re.MatchString(a) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Parse tokenizes a shell command line and returns one Command per simple
+// command it contains, descending into pipelines, &&/|| chains, subshells,
+// and { } blocks. sudo and env prefixes are stripped from Argv so rules see
+// the effective command being executed rather than the wrapper around it.
+func Parse(command string) ([]Command, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil, fmt.Errorf("parsing shell command: %w", err)
+	}
+
+	var commands []Command
+	for _, stmt := range file.Stmts {
+		commands = append(commands, walkStmt(stmt, 0, 1)...)
+	}
+	return commands, nil
+}
+
+func walkStmt(stmt *syntax.Stmt, pipelinePos, pipelineLen int) []Command {
+	switch cmd := stmt.Cmd.(type) {
+	case *syntax.CallExpr:
+		return []Command{callToCommand(stmt, cmd, pipelinePos, pipelineLen)}
+
+	case *syntax.BinaryCmd:
+		if cmd.Op == syntax.Pipe || cmd.Op == syntax.PipeAll {
+			stages := flattenPipeline(stmt)
+			var out []Command
+			for i, s := range stages {
+				out = append(out, walkStmt(s, i, len(stages))...)
+			}
+			return out
+		}
+		// &&/|| don't change effective pipeline position; each side runs
+		// (or doesn't) as its own standalone command.
+		out := walkStmt(cmd.X, 0, 1)
+		return append(out, walkStmt(cmd.Y, 0, 1)...)
+
+	case *syntax.Block:
+		var out []Command
+		for _, s := range cmd.Stmts {
+			out = append(out, walkStmt(s, 0, 1)...)
+		}
+		return out
+
+	case *syntax.Subshell:
+		var out []Command
+		for _, s := range cmd.Stmts {
+			out = append(out, walkStmt(s, 0, 1)...)
+		}
+		return out
+
+	default:
+		// Loops, conditionals, function defs, etc. aren't walked: they
+		// don't represent a command that runs unconditionally, and the
+		// legacy regex path still sees the raw text as a fallback.
+		return nil
+	}
+}
+
+// flattenPipeline unrolls a right-leaning chain of `|`/`|&` BinaryCmds into
+// its individual stages, in left-to-right execution order.
+func flattenPipeline(stmt *syntax.Stmt) []*syntax.Stmt {
+	bc, ok := stmt.Cmd.(*syntax.BinaryCmd)
+	if !ok || (bc.Op != syntax.Pipe && bc.Op != syntax.PipeAll) {
+		return []*syntax.Stmt{stmt}
+	}
+	return append(flattenPipeline(bc.X), flattenPipeline(bc.Y)...)
+}
+
+func callToCommand(stmt *syntax.Stmt, call *syntax.CallExpr, pos, length int) Command {
+	var argv []string
+	for _, w := range call.Args {
+		argv = append(argv, wordString(w))
+	}
+	argv = stripPrefixes(argv)
+
+	var redirects []Redirect
+	for _, r := range stmt.Redirs {
+		redirects = append(redirects, Redirect{Op: r.Op.String(), Target: wordString(r.Word)})
+	}
+
+	return Command{
+		Argv:        argv,
+		Redirects:   redirects,
+		PipelinePos: pos,
+		PipelineLen: length,
+		Start:       int(stmt.Pos().Offset()),
+		End:         int(stmt.End().Offset()),
+	}
+}
+
+// stripPrefixes drops leading `sudo [flags]` and `env [flags|KEY=VAL]...`
+// wrappers so a rule matching on "rm" also fires on "sudo rm" and
+// "env FOO=bar rm", not just on rm run directly.
+func stripPrefixes(argv []string) []string {
+	for len(argv) > 0 {
+		switch argv[0] {
+		case "sudo":
+			argv = argv[1:]
+			for len(argv) > 0 && strings.HasPrefix(argv[0], "-") {
+				argv = argv[1:]
+			}
+			continue
+		case "env":
+			argv = argv[1:]
+			for len(argv) > 0 && (strings.HasPrefix(argv[0], "-") || strings.Contains(argv[0], "=")) {
+				argv = argv[1:]
+			}
+			continue
+		}
+		break
+	}
+	return argv
+}
+
+// wordString renders a shell word back to a plain string. Purely literal
+// words (no variable/command substitution) are concatenated directly;
+// anything else falls back to the syntax printer's best-effort rendering,
+// since a rule can't statically know what a substitution will expand to.
+func wordString(w *syntax.Word) string {
+	if w == nil {
+		return ""
+	}
+
+	var lit strings.Builder
+	allLit := true
+	for _, part := range w.Parts {
+		l, ok := part.(*syntax.Lit)
+		if !ok {
+			allLit = false
+			break
+		}
+		lit.WriteString(l.Value)
+	}
+	if allLit {
+		return lit.String()
+	}
+
+	var buf strings.Builder
+	if err := syntax.NewPrinter().Print(&buf, w); err != nil {
+		return ""
+	}
+	return buf.String()
+}
@@ -1,8 +1,16 @@
 package safety
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/REDFOX1899/ask-sh/internal/safety/parse"
 )
 
 // RiskLevel indicates how dangerous a command is
@@ -21,258 +29,701 @@ type RiskAssessment struct {
 	Level       RiskLevel
 	Warnings    []string
 	Suggestions []string
+
+	// Hits carries the full detail behind each Warning, including the
+	// rule ID it came from, for callers that want more than free text.
+	Hits []RuleHit
+
+	// Denied is true when the command matched the RuleSet's Deny list. A
+	// denied command must never execute, typed confirmation or not; it
+	// takes priority over Allow and every per-rule severity.
+	Denied bool
+
+	// Allowed is true when the command matched the RuleSet's Allow list
+	// (and wasn't also Denied). An allowed command is trusted outright:
+	// Level is forced to RiskNone and no rule Warnings apply.
+	Allowed bool
 }
 
-// DangerousPattern defines a pattern to detect dangerous commands
-type DangerousPattern struct {
-	Pattern     *regexp.Regexp
-	Description string
+// RuleHit records which rule matched a command and the detail it carries.
+// Start and End are byte offsets of the offending token into the original
+// command string, so a caller like the TUI can highlight it; they're left
+// at 0 for legacy regex rules, which don't have a single token to point at.
+type RuleHit struct {
+	RuleID      string
+	Category    string
 	Level       RiskLevel
+	Description string
 	Suggestion  string
+	DocURL      string
+	Start, End  int
+}
+
+// Rule is a single safety rule: a stable ID, a category, a severity, a
+// human description, and a suggested fix - mirroring how hadolint models
+// rules with code, severity, and message. It matches a command one of two
+// ways: legacy Patterns are regexes run against the raw, lowercased
+// command text; Cmd/Flags/ArgPattern are a structured matcher run against
+// each simple command parsed out of the shell syntax, which is immune to
+// the false positives/negatives raw-text regexes are prone to (quoting,
+// variable expansion, argument order, case-sensitive paths). A rule uses
+// whichever it declares - Cmd set means structured, otherwise legacy.
+type Rule struct {
+	ID       string   `yaml:"id"`
+	Category string   `yaml:"category"`
+	Severity string   `yaml:"severity"` // "low", "medium", "high", or "critical"
+	Patterns []string `yaml:"patterns,omitempty"`
+
+	Cmd        string   `yaml:"cmd,omitempty"`         // structured: exact command name, e.g. "rm"
+	Flags      []string `yaml:"flags,omitempty"`       // structured: every flag here must be present
+	ArgPattern string   `yaml:"arg_pattern,omitempty"` // structured: a regex an argument must match
+
+	Description string `yaml:"description"`
+	Suggestion  string `yaml:"suggestion"`
+	DocURL      string `yaml:"doc_url,omitempty"`
+
+	compiled  []*regexp.Regexp
+	argRegexp *regexp.Regexp
+	level     RiskLevel
+}
+
+// structured reports whether the rule is evaluated against parsed argv
+// rather than raw regex text.
+func (r Rule) structured() bool {
+	return r.Cmd != ""
+}
+
+func (r Rule) matches(cmd string) bool {
+	for _, re := range r.compiled {
+		if re.MatchString(cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchStructured evaluates a structured rule against every simple command
+// parsed out of the shell line, returning the first hit found, complete
+// with the source range of the offending command for highlighting.
+func (r Rule) matchStructured(commands []parse.Command) *RuleHit {
+	for _, c := range commands {
+		if c.Name() != r.Cmd {
+			continue
+		}
+
+		allFlags := true
+		for _, flag := range r.Flags {
+			if !c.HasFlag(flag) {
+				allFlags = false
+				break
+			}
+		}
+		if !allFlags {
+			continue
+		}
+
+		if r.argRegexp != nil {
+			matched, err := c.ArgMatches(r.ArgPattern)
+			if err != nil || !matched {
+				continue
+			}
+		}
+
+		return &RuleHit{
+			RuleID:      r.ID,
+			Category:    r.Category,
+			Level:       r.level,
+			Description: r.Description,
+			Suggestion:  r.Suggestion,
+			DocURL:      r.DocURL,
+			Start:       c.Start,
+			End:         c.End,
+		}
+	}
+	return nil
+}
+
+// RuleSet is an ordered collection of compiled rules plus rule IDs that are
+// always skipped, and an allow/deny list of whole-command patterns that
+// sit above the per-rule severity: Deny blocks a command outright no
+// matter how it scores, Allow trusts it no matter what it matched.
+type RuleSet struct {
+	Rules       []Rule
+	IgnoreRules []string
+
+	Allow []string
+	Deny  []string
+
+	allowRegexps []*regexp.Regexp
+	denyRegexps  []*regexp.Regexp
+}
+
+// fileConfig is the shape of a safety.yaml file: user rules merged on top
+// of the embedded defaults, plus a global ignore list and an allow/deny
+// list of command patterns.
+type fileConfig struct {
+	Rules       []Rule   `yaml:"rules"`
+	IgnoreRules []string `yaml:"ignore_rules"`
+	Allow       []string `yaml:"allow"`
+	Deny        []string `yaml:"deny"`
+}
+
+// Default is the RuleSet used by AnalyzeCommand, built from the embedded
+// default rules with no site-specific additions. Callers that load a
+// site-specific RuleSet at startup should override it with SetDefault.
+var Default = MustBuildRuleSet(defaultRules, nil)
+
+// SetDefault overrides the RuleSet used by AnalyzeCommand.
+func SetDefault(rs *RuleSet) {
+	Default = rs
+}
+
+// DefaultPath returns ~/.x/safety.yaml, the conventional location for a
+// site-specific RuleSet.
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".x", "safety.yaml"), nil
+}
+
+// LoadRuleSet loads a RuleSet from a YAML file at path, merging its rules
+// and ignore list on top of the embedded defaults. A missing file is not an
+// error - only the defaults apply.
+func LoadRuleSet(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return BuildRuleSet(defaultRules, nil, nil, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg fileConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing safety ruleset %s: %w", path, err)
+	}
+
+	rules := append(append([]Rule{}, defaultRules...), cfg.Rules...)
+	return BuildRuleSet(rules, cfg.IgnoreRules, cfg.Allow, cfg.Deny)
+}
+
+// BuildRuleSet compiles rules' patterns and resolves their severity into a
+// RiskLevel, returning a ready-to-use RuleSet.
+func BuildRuleSet(rules []Rule, ignoreRules, allow, deny []string) (*RuleSet, error) {
+	built := make([]Rule, len(rules))
+	for i, r := range rules {
+		r.level = parseLevel(r.Severity)
+		for _, p := range r.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid pattern %q: %w", r.ID, p, err)
+			}
+			r.compiled = append(r.compiled, re)
+		}
+		if r.ArgPattern != "" {
+			re, err := regexp.Compile(r.ArgPattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid arg_pattern %q: %w", r.ID, r.ArgPattern, err)
+			}
+			r.argRegexp = re
+		}
+		built[i] = r
+	}
+
+	allowRegexps, err := compilePatterns("allow", allow)
+	if err != nil {
+		return nil, err
+	}
+	denyRegexps, err := compilePatterns("deny", deny)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RuleSet{
+		Rules:        built,
+		IgnoreRules:  ignoreRules,
+		Allow:        allow,
+		Deny:         deny,
+		allowRegexps: allowRegexps,
+		denyRegexps:  denyRegexps,
+	}, nil
+}
+
+func compilePatterns(list string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s pattern %q: %w", list, p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// MustBuildRuleSet is like BuildRuleSet but panics on error, for building
+// the embedded Default RuleSet at package init.
+func MustBuildRuleSet(rules []Rule, ignoreRules []string) *RuleSet {
+	rs, err := BuildRuleSet(rules, ignoreRules, nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	return rs
+}
+
+var ignoreAnnotationPattern = regexp.MustCompile(`(?i)x:ignore\s+([A-Za-z0-9,\-\s]+)`)
+
+// matchesAny reports whether command matches any of patterns.
+func matchesAny(patterns []*regexp.Regexp, command string) bool {
+	for _, re := range patterns {
+		if re.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIgnoreAnnotations extracts rule IDs from an inline `# x:ignore SH017`
+// style annotation anywhere in the command text.
+func parseIgnoreAnnotations(command string) []string {
+	match := ignoreAnnotationPattern.FindStringSubmatch(command)
+	if match == nil {
+		return nil
+	}
+
+	var ids []string
+	for _, id := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ' ' }) {
+		if id != "" {
+			ids = append(ids, strings.ToUpper(id))
+		}
+	}
+	return ids
+}
+
+// Analyze checks a command against every rule in the set, skipping rules in
+// IgnoreRules or named by an inline `# x:ignore` annotation on the command.
+func (rs *RuleSet) Analyze(command string) RiskAssessment {
+	assessment := RiskAssessment{
+		Level:       RiskNone,
+		Warnings:    []string{},
+		Suggestions: []string{},
+	}
+
+	if matchesAny(rs.denyRegexps, command) {
+		assessment.Denied = true
+		assessment.Level = RiskCritical
+		assessment.Warnings = append(assessment.Warnings, "Blocked by the deny list in safety.yaml")
+		return assessment
+	}
+	if matchesAny(rs.allowRegexps, command) {
+		assessment.Allowed = true
+		return assessment
+	}
+
+	ignored := make(map[string]bool, len(rs.IgnoreRules))
+	for _, id := range rs.IgnoreRules {
+		ignored[strings.ToUpper(id)] = true
+	}
+	for _, id := range parseIgnoreAnnotations(command) {
+		ignored[id] = true
+	}
+
+	cmd := strings.ToLower(strings.TrimSpace(command))
+
+	// Parsed once and reused by every structured rule. A command that fails
+	// to parse (e.g. a fragment with unbalanced quotes) just has no
+	// structured hits; the legacy regex path still runs against it below.
+	parsed, _ := parse.Parse(command)
+
+	for _, rule := range rs.Rules {
+		if ignored[strings.ToUpper(rule.ID)] {
+			continue
+		}
+
+		var hit *RuleHit
+		if rule.structured() {
+			hit = rule.matchStructured(parsed)
+		} else if rule.matches(cmd) {
+			hit = &RuleHit{
+				RuleID:      rule.ID,
+				Category:    rule.Category,
+				Level:       rule.level,
+				Description: rule.Description,
+				Suggestion:  rule.Suggestion,
+				DocURL:      rule.DocURL,
+			}
+		}
+		if hit == nil {
+			continue
+		}
+
+		if rule.level > assessment.Level {
+			assessment.Level = rule.level
+		}
+		assessment.Warnings = append(assessment.Warnings, rule.Description)
+		if rule.Suggestion != "" {
+			assessment.Suggestions = append(assessment.Suggestions, rule.Suggestion)
+		}
+		assessment.Hits = append(assessment.Hits, *hit)
+	}
+
+	return assessment
+}
+
+// HasCategory reports whether any matched rule falls under category, e.g.
+// "network" for a curl/wget pipe-into-shell hit that qualifies for
+// download-and-review instead of blind execution.
+func (a RiskAssessment) HasCategory(category string) bool {
+	for _, hit := range a.Hits {
+		if hit.Category == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Explain returns the rule with the given ID, for `x` to print its full
+// detail (e.g. a `x safety explain SH001` subcommand).
+func (rs *RuleSet) Explain(id string) (Rule, bool) {
+	for _, r := range rs.Rules {
+		if strings.EqualFold(r.ID, id) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+func parseLevel(severity string) RiskLevel {
+	switch strings.ToLower(severity) {
+	case "low":
+		return RiskLow
+	case "medium":
+		return RiskMedium
+	case "high":
+		return RiskHigh
+	case "critical":
+		return RiskCritical
+	default:
+		return RiskNone
+	}
+}
+
+// AnalyzeCommand checks a command for dangerous patterns using the default
+// RuleSet. This is a thin wrapper so existing callers (the TUI) don't need
+// to know about RuleSet at all; site-specific rules go in ~/.x/safety.yaml
+// and are picked up via LoadRuleSet instead.
+func AnalyzeCommand(command string) RiskAssessment {
+	return Default.Analyze(command)
+}
+
+// CombineModelOpinion raises assessment to at least the risk level a
+// model's own self-assessment (provider.Response.Danger: "low", "medium",
+// "high", or "") reported for the command - an optional second opinion
+// alongside the static RuleSet. It's a no-op on a Denied or Allowed
+// assessment: a deny-list match must stay blocked, and an explicit
+// allow-list trust shouldn't be second-guessed by the same model whose
+// output it's vetting.
+func CombineModelOpinion(assessment RiskAssessment, modelDanger string) RiskAssessment {
+	if assessment.Denied || assessment.Allowed {
+		return assessment
+	}
+	if level := parseLevel(modelDanger); level > assessment.Level {
+		assessment.Level = level
+		assessment.Warnings = append(assessment.Warnings, fmt.Sprintf("Model assessed this command as %s risk", modelDanger))
+	}
+	return assessment
+}
+
+// ErrDenied is returned by Gate when a command matches the RuleSet's deny
+// list and must not be offered for execution at all.
+var ErrDenied = errors.New("command blocked by safety deny list")
+
+// Gate combines AnalyzeCommand with the model's own risk self-assessment
+// via CombineModelOpinion and returns ErrDenied if the result is a hard
+// denial, so a blocked command never reaches the confirmation UI.
+func Gate(command, modelDanger string) (RiskAssessment, error) {
+	assessment := CombineModelOpinion(AnalyzeCommand(command), modelDanger)
+	if assessment.Denied {
+		return assessment, ErrDenied
+	}
+	return assessment, nil
+}
+
+// GetRiskLevelName returns a human-readable risk level name
+func GetRiskLevelName(level RiskLevel) string {
+	switch level {
+	case RiskNone:
+		return "Safe"
+	case RiskLow:
+		return "Low Risk"
+	case RiskMedium:
+		return "Medium Risk"
+	case RiskHigh:
+		return "High Risk"
+	case RiskCritical:
+		return "CRITICAL DANGER"
+	default:
+		return "Unknown"
+	}
+}
+
+// GetConfirmationWord returns the word user must type for high-risk commands
+func GetConfirmationWord(level RiskLevel) string {
+	switch level {
+	case RiskHigh:
+		return "CONFIRM"
+	case RiskCritical:
+		return "I UNDERSTAND THE RISK"
+	default:
+		return ""
+	}
 }
 
-var dangerousPatterns = []DangerousPattern{
-	// CRITICAL - System destruction
+// defaultRules is the embedded baseline RuleSet, covering the same cases
+// the hardcoded dangerousPatterns slice used to.
+var defaultRules = []Rule{
 	{
-		Pattern:     regexp.MustCompile(`rm\s+(-[a-zA-Z]*[rf][a-zA-Z]*\s+)*(/|/\*|\s+/\s|"\s*/\s*")`),
+		ID:          "SH001",
+		Category:    "destruction",
+		Severity:    "critical",
+		Cmd:         "rm",
+		Flags:       []string{"-r", "-f"},
+		ArgPattern:  `^/(\*?)$`,
 		Description: "Removes root filesystem - THIS WILL DESTROY YOUR SYSTEM",
-		Level:       RiskCritical,
 		Suggestion:  "Never run rm -rf on root. Specify the exact path you want to delete.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`rm\s+(-[a-zA-Z]*[rf][a-zA-Z]*\s+)*(~|~/\*|/home/\*|/Users/\*)`),
+		ID:          "SH002",
+		Category:    "destruction",
+		Severity:    "critical",
+		Patterns:    []string{`rm\s+(-[a-zA-Z]*[rf][a-zA-Z]*\s+)*(~|~/\*|/home/\*|/Users/\*)`},
 		Description: "Removes entire home directory",
-		Level:       RiskCritical,
 		Suggestion:  "Specify the exact subdirectory you want to delete.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`mkfs\s`),
+		ID:          "SH003",
+		Category:    "destruction",
+		Severity:    "critical",
+		Patterns:    []string{`mkfs\s`},
 		Description: "Formats a filesystem - ALL DATA WILL BE LOST",
-		Level:       RiskCritical,
 		Suggestion:  "Double-check the device path. This is irreversible.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`dd\s+.*of\s*=\s*/dev/(sd[a-z]|nvme|hd[a-z]|disk)\b`),
+		ID:          "SH004",
+		Category:    "destruction",
+		Severity:    "critical",
+		Patterns:    []string{`dd\s+.*of\s*=\s*/dev/(sd[a-z]|nvme|hd[a-z]|disk)\b`},
 		Description: "Writes directly to disk - CAN DESTROY DATA",
-		Level:       RiskCritical,
 		Suggestion:  "Verify the output device is correct. Consider backing up first.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`>\s*/dev/(sd[a-z]|nvme|hd[a-z])`),
+		ID:          "SH005",
+		Category:    "destruction",
+		Severity:    "critical",
+		Patterns:    []string{`>\s*/dev/(sd[a-z]|nvme|hd[a-z])`},
 		Description: "Redirects output to raw disk device",
-		Level:       RiskCritical,
 		Suggestion:  "This will overwrite the disk. Use a file path instead.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`:(){ :|:& };:`),
+		ID:          "SH006",
+		Category:    "destruction",
+		Severity:    "critical",
+		Patterns:    []string{`:\(\)\{ :\|:& \};:`},
 		Description: "Fork bomb - WILL CRASH YOUR SYSTEM",
-		Level:       RiskCritical,
 		Suggestion:  "This is a malicious command. Do not run it.",
 	},
-
-	// HIGH - Data loss potential
 	{
-		Pattern:     regexp.MustCompile(`rm\s+(-[a-zA-Z]*[rf][a-zA-Z]*\s+)+`),
+		ID:          "SH007",
+		Category:    "destruction",
+		Severity:    "high",
+		Patterns:    []string{`rm\s+(-[a-zA-Z]*[rf][a-zA-Z]*\s+)+`},
 		Description: "Recursive/forced deletion",
-		Level:       RiskHigh,
 		Suggestion:  "Consider using 'rm -i' for interactive confirmation, or list files first with 'ls'.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`chmod\s+(-R\s+)?(000|777)\s`),
+		ID:          "SH008",
+		Category:    "permission",
+		Severity:    "high",
+		Patterns:    []string{`chmod\s+(-R\s+)?(000|777)\s`},
 		Description: "Dangerous permission change",
-		Level:       RiskHigh,
 		Suggestion:  "777 makes files world-writable. 000 removes all access. Use more specific permissions.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`chmod\s+-R\s`),
+		ID:          "SH009",
+		Category:    "permission",
+		Severity:    "medium",
+		Patterns:    []string{`chmod\s+-R\s`},
 		Description: "Recursive permission change",
-		Level:       RiskMedium,
 		Suggestion:  "Verify the target directory before applying recursive permission changes.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`chown\s+-R\s`),
+		ID:          "SH010",
+		Category:    "permission",
+		Severity:    "medium",
+		Patterns:    []string{`chown\s+-R\s`},
 		Description: "Recursive ownership change",
-		Level:       RiskMedium,
 		Suggestion:  "Verify the target directory and new owner before applying.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`>\s*/etc/`),
+		ID:          "SH011",
+		Category:    "destruction",
+		Severity:    "high",
+		Patterns:    []string{`>\s*/etc/`},
 		Description: "Overwrites system configuration file",
-		Level:       RiskHigh,
 		Suggestion:  "Back up the original file first. Consider using '>>' to append instead.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`dd\s+`),
+		ID:          "SH012",
+		Category:    "destruction",
+		Severity:    "high",
+		Patterns:    []string{`dd\s+`},
 		Description: "Low-level disk operation",
-		Level:       RiskHigh,
 		Suggestion:  "Double-check if= and of= parameters. Data can be lost if reversed.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`mv\s+.*\s+/dev/null`),
+		ID:          "SH013",
+		Category:    "destruction",
+		Severity:    "high",
+		Patterns:    []string{`mv\s+.*\s+/dev/null`},
 		Description: "Moving files to /dev/null deletes them permanently",
-		Level:       RiskHigh,
 		Suggestion:  "Use 'rm' if you want to delete. This is irreversible.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`curl\s+.*\|\s*(sudo\s+)?(ba)?sh`),
+		ID:          "SH014",
+		Category:    "network",
+		Severity:    "high",
+		Patterns:    []string{`curl\s+.*\|\s*(sudo\s+)?(ba)?sh`},
 		Description: "Piping remote script directly to shell",
-		Level:       RiskHigh,
 		Suggestion:  "Download the script first, review it, then execute.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`wget\s+.*\|\s*(sudo\s+)?(ba)?sh`),
+		ID:          "SH015",
+		Category:    "network",
+		Severity:    "high",
+		Patterns:    []string{`wget\s+.*\|\s*(sudo\s+)?(ba)?sh`},
 		Description: "Piping remote script directly to shell",
-		Level:       RiskHigh,
 		Suggestion:  "Download the script first, review it, then execute.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`eval\s+.*\$`),
+		ID:          "SH016",
+		Category:    "misc",
+		Severity:    "high",
+		Patterns:    []string{`eval\s+.*\$`},
 		Description: "Executing dynamically constructed command",
-		Level:       RiskHigh,
 		Suggestion:  "Avoid eval when possible. It can execute unintended code.",
 	},
-
-	// MEDIUM - Potential issues
 	{
-		Pattern:     regexp.MustCompile(`sudo\s+rm\s`),
+		ID:          "SH017",
+		Category:    "privilege",
+		Severity:    "medium",
+		Patterns:    []string{`sudo\s+rm\s`},
 		Description: "Deleting files with elevated privileges",
-		Level:       RiskMedium,
 		Suggestion:  "Verify the files to be deleted before running with sudo.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`sudo\s+`),
+		ID:          "SH018",
+		Category:    "privilege",
+		Severity:    "low",
+		Patterns:    []string{`sudo\s+`},
 		Description: "Running with elevated privileges",
-		Level:       RiskLow,
 		Suggestion:  "Command runs as root. Verify this is necessary.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`rm\s`),
+		ID:          "SH019",
+		Category:    "destruction",
+		Severity:    "low",
+		Patterns:    []string{`rm\s`},
 		Description: "Deleting files",
-		Level:       RiskLow,
 		Suggestion:  "Consider using trash/recycle instead of permanent deletion.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`kill\s+-9`),
+		ID:          "SH020",
+		Category:    "process",
+		Severity:    "medium",
+		Patterns:    []string{`kill\s+-9`},
 		Description: "Force killing process",
-		Level:       RiskMedium,
 		Suggestion:  "SIGKILL doesn't allow graceful shutdown. Try 'kill' without -9 first.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`killall\s`),
+		ID:          "SH021",
+		Category:    "process",
+		Severity:    "medium",
+		Patterns:    []string{`killall\s`},
 		Description: "Killing all processes by name",
-		Level:       RiskMedium,
 		Suggestion:  "This affects ALL processes with that name. Be specific.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`pkill\s`),
+		ID:          "SH022",
+		Category:    "process",
+		Severity:    "medium",
+		Patterns:    []string{`pkill\s`},
 		Description: "Killing processes by pattern",
-		Level:       RiskMedium,
 		Suggestion:  "Verify which processes will be affected with 'pgrep' first.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`shutdown|reboot|poweroff|halt`),
+		ID:          "SH023",
+		Category:    "service",
+		Severity:    "medium",
+		Patterns:    []string{`shutdown|reboot|poweroff|halt`},
 		Description: "System shutdown/reboot",
-		Level:       RiskMedium,
 		Suggestion:  "This will terminate all running programs.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`systemctl\s+(stop|disable|mask)\s`),
+		ID:          "SH024",
+		Category:    "service",
+		Severity:    "medium",
+		Patterns:    []string{`systemctl\s+(stop|disable|mask)\s`},
 		Description: "Stopping/disabling system service",
-		Level:       RiskMedium,
 		Suggestion:  "Verify this won't affect critical system functionality.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`iptables\s+-F`),
+		ID:          "SH025",
+		Category:    "firewall",
+		Severity:    "high",
+		Patterns:    []string{`iptables\s+-F`},
 		Description: "Flushing firewall rules",
-		Level:       RiskHigh,
 		Suggestion:  "This removes all firewall rules. Your system may become exposed.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`ufw\s+disable`),
+		ID:          "SH026",
+		Category:    "firewall",
+		Severity:    "high",
+		Patterns:    []string{`ufw\s+disable`},
 		Description: "Disabling firewall",
-		Level:       RiskHigh,
 		Suggestion:  "This disables the firewall entirely. Your system may become exposed.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`history\s+-c`),
+		ID:          "SH027",
+		Category:    "misc",
+		Severity:    "low",
+		Patterns:    []string{`history\s+-c`},
 		Description: "Clearing shell history",
-		Level:       RiskLow,
 		Suggestion:  "This is often used to hide malicious activity.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`shred\s`),
+		ID:          "SH028",
+		Category:    "destruction",
+		Severity:    "high",
+		Patterns:    []string{`shred\s`},
 		Description: "Securely erasing files (unrecoverable)",
-		Level:       RiskHigh,
 		Suggestion:  "Shredded files cannot be recovered. Verify targets carefully.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`truncate\s`),
+		ID:          "SH029",
+		Category:    "destruction",
+		Severity:    "medium",
+		Patterns:    []string{`truncate\s`},
 		Description: "Truncating files",
-		Level:       RiskMedium,
 		Suggestion:  "This can cause data loss. Verify the target file.",
 	},
 	{
-		Pattern:     regexp.MustCompile(`>\s*[^|&]`),
+		ID:          "SH030",
+		Category:    "destruction",
+		Severity:    "low",
+		Patterns:    []string{`>\s*[^|&]`},
 		Description: "Overwriting file with redirect",
-		Level:       RiskLow,
 		Suggestion:  "This overwrites the file. Use '>>' to append instead if needed.",
 	},
 }
-
-// AnalyzeCommand checks a command for dangerous patterns
-func AnalyzeCommand(command string) RiskAssessment {
-	assessment := RiskAssessment{
-		Level:       RiskNone,
-		Warnings:    []string{},
-		Suggestions: []string{},
-	}
-
-	// Normalize command
-	cmd := strings.TrimSpace(command)
-	cmd = strings.ToLower(cmd)
-
-	for _, pattern := range dangerousPatterns {
-		if pattern.Pattern.MatchString(cmd) {
-			// Update to highest risk level found
-			if pattern.Level > assessment.Level {
-				assessment.Level = pattern.Level
-			}
-			assessment.Warnings = append(assessment.Warnings, pattern.Description)
-			if pattern.Suggestion != "" {
-				assessment.Suggestions = append(assessment.Suggestions, pattern.Suggestion)
-			}
-		}
-	}
-
-	return assessment
-}
-
-// GetRiskLevelName returns a human-readable risk level name
-func GetRiskLevelName(level RiskLevel) string {
-	switch level {
-	case RiskNone:
-		return "Safe"
-	case RiskLow:
-		return "Low Risk"
-	case RiskMedium:
-		return "Medium Risk"
-	case RiskHigh:
-		return "High Risk"
-	case RiskCritical:
-		return "CRITICAL DANGER"
-	default:
-		return "Unknown"
-	}
-}
-
-// GetConfirmationWord returns the word user must type for high-risk commands
-func GetConfirmationWord(level RiskLevel) string {
-	switch level {
-	case RiskHigh:
-		return "CONFIRM"
-	case RiskCritical:
-		return "I UNDERSTAND THE RISK"
-	default:
-		return ""
-	}
-}
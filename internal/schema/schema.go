@@ -0,0 +1,38 @@
+// Package schema defines the structured shape providers are asked to
+// return instead of a bare shell command string, so parsing no longer
+// depends on the model obeying prompt instructions like "return only the
+// command".
+package schema
+
+// CommandResult is the structured output a provider returns in structured
+// mode.
+type CommandResult struct {
+	Command      string `json:"command"`
+	Explanation  string `json:"explanation"`
+	Danger       string `json:"danger"` // "low", "medium", or "high"
+	RequiresSudo bool   `json:"requires_sudo"`
+}
+
+// Name is the schema/tool name providers are asked to use.
+const Name = "command_result"
+
+// Description documents the schema for tool-calling providers.
+const Description = "The generated shell command, a short explanation, and a risk assessment."
+
+// JSONSchema is the JSON Schema describing CommandResult, shared by the
+// providers that accept a schema directly (OpenAI response_format,
+// Anthropic tool input_schema, Gemini responseSchema).
+var JSONSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"command":     map[string]interface{}{"type": "string"},
+		"explanation": map[string]interface{}{"type": "string"},
+		"danger": map[string]interface{}{
+			"type": "string",
+			"enum": []string{"low", "medium", "high"},
+		},
+		"requires_sudo": map[string]interface{}{"type": "boolean"},
+	},
+	"required":             []string{"command", "explanation", "danger", "requires_sudo"},
+	"additionalProperties": false,
+}
@@ -0,0 +1,171 @@
+// Package session persists REPL prompt/command/outcome history so it
+// survives across runs, backing both "x repl"'s up-arrow recall and its
+// /history slash-command.
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is one REPL turn: what was asked, what command was generated for
+// it, and how it was resolved.
+type Entry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Prompt     string    `json:"prompt"`
+	Command    string    `json:"command"`
+	Provider   string    `json:"provider,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	RiskLevel  string    `json:"risk_level,omitempty"`
+	Outcome    string    `json:"outcome,omitempty"` // "executed", "failed", "cancelled", ...
+	ExitCode   int       `json:"exit_code,omitempty"`
+	StderrTail string    `json:"stderr_tail,omitempty"` // last lines of output when Outcome == "failed"
+}
+
+// History appends to and reads back ~/.x/history.jsonl, one JSON object
+// per line so it can also be tailed or grepped like a shell history file.
+type History struct {
+	path string
+}
+
+// DefaultPath returns ~/.x/history.jsonl.
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".x", "history.jsonl"), nil
+}
+
+// Open opens the history file at its default location, creating ~/.x if
+// needed. The file itself is created lazily, on the first Append.
+func Open() (*History, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return &History{path: path}, nil
+}
+
+// Append records one entry, stamping it with the current time.
+func (h *History) Append(e Entry) error {
+	e.Timestamp = time.Now()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// All reads every recorded entry, oldest first. A history file that
+// doesn't exist yet is not an error - it just means there's no history.
+func (h *History) All() ([]Entry, error) {
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Prompts returns just the prompt text of every entry, oldest first, for
+// seeding a readline-style recall buffer.
+func (h *History) Prompts() ([]string, error) {
+	entries, err := h.All()
+	if err != nil {
+		return nil, err
+	}
+	prompts := make([]string, len(entries))
+	for i, e := range entries {
+		prompts[i] = e.Prompt
+	}
+	return prompts, nil
+}
+
+// SimilarFailures returns up to limit past "failed" entries whose prompt
+// shares the most words with prompt, most-similar first. It's a
+// deliberately simple bag-of-words overlap, used as few-shot context for
+// the "ask AI to fix" loop - a real semantic index is a separate concern.
+func (h *History) SimilarFailures(prompt string, limit int) ([]Entry, error) {
+	entries, err := h.All()
+	if err != nil {
+		return nil, err
+	}
+
+	words := wordSet(prompt)
+	type scored struct {
+		entry Entry
+		score int
+	}
+	var candidates []scored
+	for _, e := range entries {
+		if e.Outcome != "failed" {
+			continue
+		}
+		if score := overlap(words, wordSet(e.Prompt)); score > 0 {
+			candidates = append(candidates, scored{e, score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	out := make([]Entry, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.entry
+	}
+	return out, nil
+}
+
+func wordSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(strings.ToLower(s)) {
+		set[w] = true
+	}
+	return set
+}
+
+func overlap(a, b map[string]bool) int {
+	n := 0
+	for w := range a {
+		if b[w] {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+
+	xexec "github.com/REDFOX1899/ask-sh/internal/exec"
+)
+
+// ExecAction is what the user chose to do after watching a command run.
+type ExecAction int
+
+const (
+	ExecActionDone ExecAction = iota // dismissed the result, nothing more to do
+	ExecActionFix                    // asked the AI to fix a failed command
+)
+
+// ExecResult is the outcome of RunExecUI: how the command exited plus
+// what the user wants to do about it.
+type ExecResult struct {
+	Action   ExecAction
+	ExitCode int
+	Duration time.Duration
+	Tail     string
+}
+
+// execOutputMsg carries a chunk of live command output into the Bubble
+// Tea update loop.
+type execOutputMsg struct{ chunk string }
+
+// execDoneMsg carries the final Result once the command has exited.
+type execDoneMsg struct {
+	result *xexec.Result
+	err    error
+}
+
+// execWriter forwards everything written to it to a running Bubble Tea
+// program as execOutputMsg, so exec.Run's live output reaches the
+// viewport without the runner caring that it's talking to a TUI.
+type execWriter struct {
+	program *tea.Program
+}
+
+func (w *execWriter) Write(p []byte) (int, error) {
+	w.program.Send(execOutputMsg{chunk: string(p)})
+	return len(p), nil
+}
+
+type execModel struct {
+	command  string
+	viewport viewport.Model
+	output   strings.Builder
+
+	done   bool
+	result *xexec.Result
+	err    error
+	action ExecAction
+}
+
+func newExecModel(command string) execModel {
+	return execModel{command: command, viewport: viewport.New(80, 20)}
+}
+
+func (m execModel) Init() tea.Cmd { return nil }
+
+func (m execModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.viewport.Width = msg.Width
+		m.viewport.Height = msg.Height - 7
+		return m, nil
+
+	case execOutputMsg:
+		m.output.WriteString(msg.chunk)
+		m.viewport.SetContent(m.output.String())
+		m.viewport.GotoBottom()
+		return m, nil
+
+	case execDoneMsg:
+		m.done = true
+		m.result = msg.result
+		m.err = msg.err
+		return m, nil
+
+	case tea.KeyMsg:
+		if !m.done {
+			// The command is still running; only scrolling makes sense.
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+		switch msg.String() {
+		case "f", "F":
+			if m.result != nil && m.result.ExitCode != 0 {
+				m.action = ExecActionFix
+				return m, tea.Quit
+			}
+		case "q", "Q", "enter", "esc", "ctrl+c":
+			m.action = ExecActionDone
+			return m, tea.Quit
+		default:
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+func (m execModel) View() string {
+	var b strings.Builder
+	b.WriteString(providerStyle.Render("$ " + m.command))
+	b.WriteString("\n\n")
+	b.WriteString(m.viewport.View())
+	b.WriteString("\n\n")
+
+	if !m.done {
+		b.WriteString(helpStyle.Render("Running... (↑/↓ to scroll)"))
+		return b.String()
+	}
+
+	if m.err != nil {
+		b.WriteString(warningTextStyle.Render(fmt.Sprintf("Failed to run command: %v", m.err)))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render(keyStyle.Render("q/Enter") + " Dismiss"))
+		return b.String()
+	}
+
+	elapsed := m.result.Duration.Round(time.Millisecond)
+	if m.result.ExitCode == 0 {
+		b.WriteString(successStyle.Render(fmt.Sprintf("Exited 0 (%s)", elapsed)))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render(keyStyle.Render("q/Enter") + " Dismiss"))
+	} else {
+		b.WriteString(commandTextDangerStyle.Render(fmt.Sprintf("Exited %d (%s)", m.result.ExitCode, elapsed)))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render(keyStyle.Render("f") + " 🔁 Ask AI to fix  •  " + keyStyle.Render("q") + " Dismiss"))
+	}
+
+	return b.String()
+}
+
+// RunExecUI runs command live under internal/exec, streaming its output
+// into a scrollable pane, and blocks until the user dismisses the result
+// or asks the AI to fix a failure.
+func RunExecUI(command string) (ExecResult, error) {
+	model := newExecModel(command)
+	p := tea.NewProgram(model)
+
+	go func() {
+		result, err := xexec.Run(command, &execWriter{program: p})
+		p.Send(execDoneMsg{result: result, err: err})
+	}()
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return ExecResult{}, err
+	}
+
+	m := finalModel.(execModel)
+	if m.err != nil {
+		return ExecResult{}, m.err
+	}
+
+	res := ExecResult{Action: m.action}
+	if m.result != nil {
+		res.ExitCode = m.result.ExitCode
+		res.Duration = m.result.Duration
+		res.Tail = m.result.Tail
+	}
+	return res, nil
+}
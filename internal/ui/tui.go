@@ -8,6 +8,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/REDFOX1899/ask-sh/internal/dryrun"
 	"github.com/REDFOX1899/ask-sh/internal/safety"
 )
 
@@ -21,6 +22,14 @@ const (
 	ActionEdit
 	ActionRefine
 	ActionExplain
+	// ActionExplainRisk renders the full detail (rule ID, category,
+	// suggestion, doc URL) behind every rule the safety analyzer matched,
+	// for commands the warning box only summarizes.
+	ActionExplainRisk
+	// ActionDownloadReview fetches the payload of a NETWORK-MUTATING
+	// pipe-into-shell command (curl/wget | sh) into a tempfile and
+	// reclassifies its contents instead of running it blind.
+	ActionDownloadReview
 )
 
 // Result contains the TUI result
@@ -30,6 +39,19 @@ type Result struct {
 	RefinementQuery string // User's refinement request
 }
 
+// ExplainChunk is one piece of an explanation streamed into a running TUI,
+// the same producer/consumer split RunExecUI uses for live command output:
+// a goroutine feeds chunks in as they arrive and the Bubble Tea model
+// re-renders as each one lands.
+type ExplainChunk struct {
+	Text string // incremental text delta, may be empty on the final chunk
+	Done bool   // true once the explanation is complete
+	Err  error  // set if streaming failed; no more chunks follow
+}
+
+// explainChunkMsg carries an ExplainChunk into the Bubble Tea update loop.
+type explainChunkMsg ExplainChunk
+
 // Styles
 var (
 	titleStyle = lipgloss.NewStyle().
@@ -57,6 +79,11 @@ var (
 				Bold(true).
 				Foreground(lipgloss.Color("196"))
 
+	commandHighlightStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("231")).
+				Background(lipgloss.Color("196"))
+
 	explanationBoxStyle = lipgloss.NewStyle().
 				Border(lipgloss.RoundedBorder()).
 				BorderForeground(lipgloss.Color("240")).
@@ -140,7 +167,16 @@ type Model struct {
 	// Safety assessment
 	riskAssessment safety.RiskAssessment
 
+	// dryRunResult holds the last preview rendered by the "d" key. It's
+	// nil until the user asks for one. RiskCritical commands require a
+	// non-nil dryRunResult before the typed-confirmation prompt unlocks.
+	dryRunResult *dryrun.Result
+	dryRunError  string
+
 	showExplanation bool
+	explaining      bool   // true while an explanation is still streaming in
+	explainErr      string // set if streaming the explanation failed
+	cancelExplain   func() // aborts the in-flight explanation request, if any
 	editMode        bool
 	refineMode      bool
 	confirmMode     bool // For dangerous commands requiring typed confirmation
@@ -189,7 +225,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		return m, nil
 
+	case explainChunkMsg:
+		if msg.Err != nil {
+			m.explainErr = msg.Err.Error()
+			m.explaining = false
+			return m, nil
+		}
+		m.explanation += msg.Text
+		if msg.Done {
+			m.explaining = false
+		}
+		return m, nil
+
 	case tea.KeyMsg:
+		// While an explanation is still streaming in, only let the user
+		// interrupt it; every other action needs the final command view.
+		if m.explaining {
+			switch msg.String() {
+			case "esc", "ctrl+c", "q":
+				if m.cancelExplain != nil {
+					m.cancelExplain()
+				}
+				m.explaining = false
+			}
+			return m, nil
+		}
+
 		// Handle edit mode
 		if m.editMode {
 			switch msg.String() {
@@ -197,8 +258,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.command = m.textInput.Value()
 				m.editMode = false
 				m.textInput.Blur()
-				// Re-analyze command after edit
+				// Re-analyze command after edit; any prior dry-run no
+				// longer describes what this command would do.
 				m.riskAssessment = safety.AnalyzeCommand(m.command)
+				m.dryRunResult = nil
+				m.dryRunError = ""
 				return m, nil
 			case "esc":
 				m.editMode = false
@@ -262,6 +326,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Normal mode key handling
 		switch msg.String() {
 		case "y", "Y", "enter":
+			// A command matching the safety.yaml deny list can never be
+			// executed from this prompt, typed confirmation or not.
+			if m.riskAssessment.Denied {
+				m.dryRunError = "This command is blocked by the deny list in safety.yaml"
+				return m, nil
+			}
+			// RiskCritical commands must be dry-run at least once before
+			// the typed-confirmation prompt unlocks.
+			if m.riskAssessment.Level >= safety.RiskCritical && m.dryRunResult == nil {
+				m.dryRunError = "Press 'd' to preview this command's effect before confirming"
+				return m, nil
+			}
 			// Check if dangerous command requires typed confirmation
 			if m.riskAssessment.Level >= safety.RiskHigh {
 				m.confirmMode = true
@@ -274,6 +350,38 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.done = true
 			return m, tea.Quit
 
+		case "d", "D":
+			result, err := dryrun.Preview(m.command)
+			if err == nil && !result.Supported {
+				// Fall back to tracing for commands dryrun can't parse
+				// structurally; Trace's own errors are surfaced the same
+				// way as Preview's.
+				result, err = dryrun.Trace(m.command)
+			}
+			if err != nil {
+				m.dryRunError = fmt.Sprintf("dry run failed: %v", err)
+				return m, nil
+			}
+			m.dryRunResult = result
+			m.dryRunError = ""
+			return m, nil
+
+		case "i", "I":
+			if len(m.riskAssessment.Hits) == 0 {
+				return m, nil
+			}
+			m.result = Result{Action: ActionExplainRisk, Command: m.command}
+			m.done = true
+			return m, tea.Quit
+
+		case "w", "W":
+			if !m.riskAssessment.HasCategory("network") {
+				return m, nil
+			}
+			m.result = Result{Action: ActionDownloadReview, Command: m.command}
+			m.done = true
+			return m, tea.Quit
+
 		case "n", "N", "q", "esc":
 			m.result = Result{Action: ActionCancel, Command: m.command}
 			m.done = true
@@ -326,7 +434,10 @@ func (m Model) View() string {
 	b.WriteString("\n\n")
 
 	// Risk badge
-	if m.riskAssessment.Level > safety.RiskNone {
+	if m.riskAssessment.Denied {
+		b.WriteString(riskBadgeStyles[safety.RiskCritical].Render("🚫 BLOCKED BY POLICY 🚫"))
+		b.WriteString("\n\n")
+	} else if m.riskAssessment.Level > safety.RiskNone {
 		riskStyle := riskBadgeStyles[m.riskAssessment.Level]
 		riskName := safety.GetRiskLevelName(m.riskAssessment.Level)
 		if m.riskAssessment.Level >= safety.RiskHigh {
@@ -338,16 +449,38 @@ func (m Model) View() string {
 	}
 
 	// Command box (red border for dangerous commands)
-	var commandContent string
+	baseStyle := commandTextStyle
+	boxStyle := commandBoxStyle
 	if m.riskAssessment.Level >= safety.RiskHigh {
-		commandContent = commandTextDangerStyle.Render(m.command)
-		b.WriteString(commandBoxDangerStyle.Render(commandContent))
-	} else {
-		commandContent = commandTextStyle.Render(m.command)
-		b.WriteString(commandBoxStyle.Render(commandContent))
+		baseStyle = commandTextDangerStyle
+		boxStyle = commandBoxDangerStyle
 	}
+	commandContent := renderCommand(m.command, m.riskAssessment, baseStyle)
+	b.WriteString(boxStyle.Render(commandContent))
 	b.WriteString("\n")
 
+	// Dry-run preview, rendered above the safety warnings
+	if m.dryRunResult != nil {
+		var dryRunContent strings.Builder
+		dryRunContent.WriteString(suggestionStyle.Render("🔍 DRY RUN"))
+		dryRunContent.WriteString("\n\n")
+		dryRunContent.WriteString(warningTextStyle.Render(m.dryRunResult.Summary()))
+		for _, line := range m.dryRunResult.Tree() {
+			dryRunContent.WriteString("\n")
+			dryRunContent.WriteString(descStyle.Render(line))
+		}
+		for _, note := range m.dryRunResult.Notes {
+			dryRunContent.WriteString("\n")
+			dryRunContent.WriteString(suggestionStyle.Render("  " + note))
+		}
+		b.WriteString(explanationBoxStyle.Render(dryRunContent.String()))
+		b.WriteString("\n")
+	}
+	if m.dryRunError != "" {
+		b.WriteString(warningTextStyle.Render(m.dryRunError))
+		b.WriteString("\n\n")
+	}
+
 	// Warning box for dangerous commands
 	if len(m.riskAssessment.Warnings) > 0 && m.riskAssessment.Level >= safety.RiskMedium {
 		var warningContent strings.Builder
@@ -374,11 +507,22 @@ func (m Model) View() string {
 		b.WriteString("\n")
 	}
 
-	// Explanation box (if showing)
+	// Explanation box (if showing). While still streaming, render
+	// whatever text has arrived so far so it reads as a live typewriter
+	// rather than a blank box until the full response lands.
 	if m.showExplanation && m.explanation != "" {
 		b.WriteString(explanationBoxStyle.Render(m.explanation))
 		b.WriteString("\n")
 	}
+	if m.explaining {
+		b.WriteString(helpStyle.Render("⏳ Streaming explanation... (esc to stop)"))
+		b.WriteString("\n\n")
+		return b.String()
+	}
+	if m.explainErr != "" {
+		b.WriteString(warningTextStyle.Render("Failed to explain command: " + m.explainErr))
+		b.WriteString("\n\n")
+	}
 
 	// Confirm mode for dangerous commands
 	if m.confirmMode {
@@ -412,16 +556,48 @@ func (m Model) View() string {
 	}
 
 	// Help text (different for dangerous commands)
-	if m.riskAssessment.Level >= safety.RiskHigh {
-		b.WriteString(renderDangerHelp())
-	} else {
-		b.WriteString(renderHelp())
+	switch {
+	case m.riskAssessment.Denied:
+		b.WriteString(renderDeniedHelp(m.riskAssessment))
+	case m.riskAssessment.Level >= safety.RiskHigh:
+		b.WriteString(renderDangerHelp(m.riskAssessment))
+	default:
+		b.WriteString(renderHelp(m.riskAssessment))
 	}
 
 	return b.String()
 }
 
-func renderHelp() string {
+// renderCommand styles the command text, highlighting the exact token an
+// offending rule matched if the assessment's highest-severity hit carries a
+// source range. Hits without a range (legacy regex rules) fall back to
+// styling the whole command, same as before structured matching existed.
+func renderCommand(command string, assessment safety.RiskAssessment, base lipgloss.Style) string {
+	hit := highestSeverityRangedHit(assessment.Hits)
+	if hit == nil || hit.Start < 0 || hit.End > len(command) || hit.Start >= hit.End {
+		return base.Render(command)
+	}
+
+	return base.Render(command[:hit.Start]) +
+		commandHighlightStyle.Render(command[hit.Start:hit.End]) +
+		base.Render(command[hit.End:])
+}
+
+func highestSeverityRangedHit(hits []safety.RuleHit) *safety.RuleHit {
+	var best *safety.RuleHit
+	for i := range hits {
+		h := &hits[i]
+		if h.End <= h.Start {
+			continue
+		}
+		if best == nil || h.Level > best.Level {
+			best = h
+		}
+	}
+	return best
+}
+
+func renderHelp(assessment safety.RiskAssessment) string {
 	keys := []struct {
 		key  string
 		desc string
@@ -431,6 +607,13 @@ func renderHelp() string {
 		{"e", "Edit command"},
 		{"r", "Refine with AI"},
 		{"x", "Explain command"},
+		{"d", "Dry-run preview"},
+	}
+	if len(assessment.Hits) > 0 {
+		keys = append(keys, struct{ key, desc string }{"i", "Inspect matched rules"})
+	}
+	if assessment.HasCategory("network") {
+		keys = append(keys, struct{ key, desc string }{"w", "Download and review before running"})
 	}
 
 	var parts []string
@@ -442,7 +625,7 @@ func renderHelp() string {
 	return helpStyle.Render(strings.Join(parts, "  •  "))
 }
 
-func renderDangerHelp() string {
+func renderDangerHelp(assessment safety.RiskAssessment) string {
 	keys := []struct {
 		key   string
 		desc  string
@@ -453,6 +636,21 @@ func renderDangerHelp() string {
 		{"e", "Edit command", keyStyle},
 		{"r", "Refine with AI", keyStyle},
 		{"x", "Explain command", keyStyle},
+		{"d", "Dry-run preview (required before confirming)", keyStyle},
+	}
+	if len(assessment.Hits) > 0 {
+		keys = append(keys, struct {
+			key   string
+			desc  string
+			style lipgloss.Style
+		}{"i", "Inspect matched rules", keyStyle})
+	}
+	if assessment.HasCategory("network") {
+		keys = append(keys, struct {
+			key   string
+			desc  string
+			style lipgloss.Style
+		}{"w", "Download and review before running", keyStyle})
 	}
 
 	var parts []string
@@ -464,6 +662,31 @@ func renderDangerHelp() string {
 	return helpStyle.Render(strings.Join(parts, "  •  "))
 }
 
+// renderDeniedHelp is shown for a command blocked by the safety.yaml deny
+// list: there's no confirmation that unlocks execution, only ways out.
+func renderDeniedHelp(assessment safety.RiskAssessment) string {
+	keys := []struct {
+		key  string
+		desc string
+	}{
+		{"n/Esc", "Cancel"},
+		{"e", "Edit command"},
+		{"r", "Refine with AI"},
+		{"x", "Explain command"},
+	}
+	if len(assessment.Hits) > 0 {
+		keys = append(keys, struct{ key, desc string }{"i", "Inspect matched rules"})
+	}
+
+	var parts []string
+	for _, k := range keys {
+		part := keyStyle.Render(k.key) + " " + descStyle.Render(k.desc)
+		parts = append(parts, part)
+	}
+
+	return helpStyle.Render(strings.Join(parts, "  •  "))
+}
+
 // GetResult returns the result after the TUI exits
 func (m Model) GetResult() Result {
 	return m.result
@@ -495,3 +718,30 @@ func RunTUIWithExplanation(command, explanation, provider, modelName string) (Re
 
 	return finalModel.(Model).GetResult(), nil
 }
+
+// RunTUIStreamingExplanation runs the confirm TUI with the explanation
+// view open immediately, rendering each ExplainChunk as it arrives on
+// chunks for a live typewriter effect instead of blocking until the whole
+// explanation is generated. cancel is called if the user interrupts the
+// stream (esc/ctrl+c/q) before a Done chunk arrives, so the caller can
+// abort the underlying request rather than let it keep running unread.
+func RunTUIStreamingExplanation(command, provider, modelName string, chunks <-chan ExplainChunk, cancel func()) (Result, error) {
+	model := NewModel(command, provider, modelName)
+	model.showExplanation = true
+	model.explaining = true
+	model.cancelExplain = cancel
+	p := tea.NewProgram(model)
+
+	go func() {
+		for c := range chunks {
+			p.Send(explainChunkMsg(c))
+		}
+	}()
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return Result{Action: ActionCancel}, err
+	}
+
+	return finalModel.(Model).GetResult(), nil
+}